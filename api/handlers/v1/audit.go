@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// @Router		/audit/events [GET]
+// @Summary		Get audit events
+// @Tags        Audit
+// @Description	Here admins can see who changed what, newest first. from and to are RFC3339 times, to is exclusive.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       filters query models.AuditEventFindReq true "filters"
+// @Success		200 	{object}  models.AuditEventFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) AuditEventFind(ctx *gin.Context) {
+	var (
+		dbReq = &models.AuditEventFindReq{}
+		err   error
+	)
+
+	dbReq.Page, err = ParsePageQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid page param", nil) {
+		return
+	}
+
+	dbReq.Limit, err = ParseLimitQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid limit param", nil) {
+		return
+	}
+
+	if from := ctx.Query("from"); from != "" {
+		dbReq.From, err = time.Parse(time.RFC3339, from)
+		if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid from param", nil) {
+			return
+		}
+	}
+
+	if to := ctx.Query("to"); to != "" {
+		dbReq.To, err = time.Parse(time.RFC3339, to)
+		if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid to param", nil) {
+			return
+		}
+	}
+
+	dbReq.ActorSub = ctx.Query("actor_sub")
+	dbReq.Action = ctx.Query("action")
+	dbReq.ResourceType = ctx.Query("resource_type")
+	dbReq.ResourceId = ctx.Query("resource_id")
+
+	ctxWithCancel, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	defer cancel()
+
+	res, err := h.storage.Postgres().AuditEventFind(ctxWithCancel, dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "AuditEventFind: h.storage.Postgres().AuditEventFind()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// @Router		/cache/stats [GET]
+// @Summary		Get cache stats
+// @Tags        Cache
+// @Description	Here admins can see how often template and user reads were served from the cache since this instance started.
+// @Security    BearerAuth
+// @Produce		json
+// @Success		200 	{object}  models.CacheStatsResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) CacheStats(ctx *gin.Context) {
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", &models.CacheStatsResponse{
+		Entities: h.storage.CacheStats(),
+	})
+}
@@ -1,46 +1,59 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/otp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 func (h *handlerV1) HandleDatabaseLevelWithMessage(c *gin.Context, err error, message string, args ...interface{}) bool {
-	status_err, _ := status.FromError(err)
 	if err != nil {
-		errorCode := InternalServerError
-		statuscode := http.StatusInternalServerError
-		message := status_err.Message()
-		switch status_err.Code() {
-		case codes.NotFound:
-			errorCode = NotFound
-			statuscode = http.StatusNotFound
-		case codes.Unknown:
-			errorCode = InternalServerError
-			statuscode = http.StatusBadRequest
-			message = "Ooops something went wrong"
-		case codes.Aborted:
-			errorCode = BadRequest
-			statuscode = http.StatusBadRequest
-		case codes.InvalidArgument:
-			errorCode = BadRequest
-			statuscode = http.StatusBadRequest
-		}
+		statuscode, errorCode, responseMessage := databaseErrorStatus(err)
 
-		h.log.Error(message, err, args)
+		h.log.Ctx(c.Request.Context()).Error(message, append([]interface{}{err}, args...)...)
 		c.AbortWithStatusJSON(statuscode, models.StandardResponse{
 			Status:  errorCode,
-			Message: message,
+			Message: responseMessage,
 		})
 		return true
 	}
 	return false
 }
 
+// databaseErrorStatus returns the http status, the error code and the
+// message a storage error is responded with.
+func databaseErrorStatus(err error) (statuscode int, errorCode, message string) {
+	status_err, _ := status.FromError(err)
+	errorCode = InternalServerError
+	statuscode = http.StatusInternalServerError
+	message = status_err.Message()
+	switch status_err.Code() {
+	case codes.NotFound:
+		errorCode = NotFound
+		statuscode = http.StatusNotFound
+	case codes.Unknown:
+		errorCode = InternalServerError
+		statuscode = http.StatusBadRequest
+		message = "Ooops something went wrong"
+	case codes.Aborted:
+		errorCode = BadRequest
+		statuscode = http.StatusBadRequest
+	case codes.InvalidArgument:
+		errorCode = BadRequest
+		statuscode = http.StatusBadRequest
+	case codes.FailedPrecondition:
+		errorCode = PreconditionFailed
+		statuscode = http.StatusPreconditionFailed
+	}
+	return statuscode, errorCode, message
+}
+
 // Handles response according to err arguments. If err is nil it returns false otherwise true
 func (h *handlerV1) HandleResponse(c *gin.Context, err error, httpStatusCode int, status, message string, data any, args ...any) bool {
 	if err != nil {
@@ -51,7 +64,7 @@ func (h *handlerV1) HandleResponse(c *gin.Context, err error, httpStatusCode int
 				Data:    data,
 			})
 		} else {
-			h.log.Error(message, err, args)
+			h.log.Ctx(c.Request.Context()).Error(message, append([]interface{}{err}, args...)...)
 			c.AbortWithStatusJSON(httpStatusCode, models.StandardResponse{
 				Status:  status,
 				Message: "Internal server error",
@@ -69,3 +82,22 @@ func (h *handlerV1) HandleResponse(c *gin.Context, err error, httpStatusCode int
 
 	return false
 }
+
+// HandleOtpError responds to the errors of the otp service. If err is nil it
+// returns false otherwise true.
+func (h *handlerV1) HandleOtpError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, otp.ErrNotFound):
+		return h.HandleResponse(c, err, http.StatusBadRequest, BadRequest, "otp expired", nil)
+	case errors.Is(err, otp.ErrIncorrect):
+		return h.HandleResponse(c, err, http.StatusBadRequest, BadRequest, "otp incorrect", nil)
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		return h.HandleResponse(c, err, http.StatusTooManyRequests, TooManyRequests, "too many attempts, request a new otp", nil)
+	case errors.Is(err, otp.ErrCooldown):
+		c.Header("Retry-After", strconv.Itoa(int(h.otp.Cooldown().Seconds())))
+		return h.HandleResponse(c, err, http.StatusTooManyRequests, TooManyRequests, "otp was sent recently, try again later", nil)
+	}
+	return h.HandleResponse(c, err, http.StatusInternalServerError, InternalServerError, message, nil)
+}
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/tabular"
+)
+
+// exportFlushRows is after how many rows an export is flushed to the client.
+const exportFlushRows = 100
+
+// @Router		/template/export [GET]
+// @Summary		Export templates
+// @Tags        Template
+// @Description	Here the templates TemplateFind would find can be downloaded as CSV or NDJSON, all of them at once without paging.
+// @Description	The file is streamed while it is read from the database. CSV cells starting with =, +, - or @ are prefixed with '
+// @Description	so spreadsheets do not run them, the prefix is removed when the file is imported.
+// @Security    BearerAuth
+// @Produce		text/csv,application/x-ndjson
+// @Param       format  query    string false "csv (default) or ndjson" Enums(csv,ndjson)
+// @Param       filters query models.TemplateFindReq false "filters"
+// @Success		200 	{file}    file
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateExport(ctx *gin.Context) {
+	format, ok := h.parseFormat(ctx, ctx.DefaultQuery("format", models.FormatCSV))
+	if !ok {
+		return
+	}
+
+	dbReq := &models.TemplateFindReq{}
+	if !h.parseTemplateFilters(ctx, dbReq) {
+		return
+	}
+
+	columns := []string{
+		"id", "template_name", "description", "status", "tags", "body", "body_schema",
+		"owner_sub", "created_at", "updated_at", "version",
+	}
+	h.streamExport(ctx, format, "templates", columns, func(write func(cells []string, value any) error) error {
+		return h.storage.Postgres().TemplateExport(ctx.Request.Context(), dbReq, func(t *models.TemplateResponse) error {
+			return write([]string{
+				t.Id, t.TemplateName, t.Description, t.Status, strings.Join(t.Tags, ","), string(t.Body), string(t.BodySchema),
+				t.OwnerSub, t.CreatedAt, t.UpdatedAt, strconv.Itoa(t.Version),
+			}, t)
+		})
+	})
+}
+
+// @Router		/user/export [GET]
+// @Summary		Export users
+// @Tags        User
+// @Description	Here admins can download users as CSV or NDJSON, filtered and sorted like user lists. The file is streamed while
+// @Description	it is read from the database.
+// @Security    BearerAuth
+// @Produce		text/csv,application/x-ndjson
+// @Param       format  query    string false "csv (default) or ndjson" Enums(csv,ndjson)
+// @Param       sort    query    string false "e.g. -created_at,user_name"
+// @Success		200 	{file}    file
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) UserExport(ctx *gin.Context) {
+	format, ok := h.parseFormat(ctx, ctx.DefaultQuery("format", models.FormatCSV))
+	if !ok {
+		return
+	}
+
+	var (
+		dbReq = &models.UserFindReq{}
+		err   error
+	)
+	dbReq.Filter, err = ParseFilterQueryParams(ctx, models.UserFields)
+	if err != nil {
+		h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, err.Error(), nil)
+		return
+	}
+
+	columns := []string{"id", "user_name", "email", "role", "created_at", "updated_at", "version"}
+	h.streamExport(ctx, format, "users", columns, func(write func(cells []string, value any) error) error {
+		return h.storage.Postgres().UserExport(ctx.Request.Context(), dbReq, func(u *models.UserResponse) error {
+			return write([]string{
+				u.Id, u.UserName, u.Email, u.Role, u.CreatedAt, u.UpdatedAt, strconv.Itoa(u.Version),
+			}, models.UserExportRow{
+				Id: u.Id, UserName: u.UserName, Email: u.Email, Role: u.Role,
+				CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt, Version: u.Version,
+			})
+		})
+	})
+}
+
+// parseFormat checks format is csv or ndjson, writing the error response
+// when it is not.
+func (h *handlerV1) parseFormat(ctx *gin.Context, format string) (string, bool) {
+	if format != models.FormatCSV && format != models.FormatNDJSON {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "format should be one of csv, ndjson", nil)
+		return "", false
+	}
+	return format, true
+}
+
+// streamExport responds with the rows fn writes as an attachment, flushing
+// them every exportFlushRows rows. Errors before anything is sent are
+// responded as usual, later ones can only cut the file short.
+func (h *handlerV1) streamExport(ctx *gin.Context, format, name string, columns []string, fn func(write func(cells []string, value any) error) error) {
+	h.extendWriteDeadline(ctx)
+	out := &attachmentWriter{ctx: ctx, contentType: tabular.ContentType(format), filename: name + "." + format}
+	w := tabular.NewWriter(out, format, columns)
+
+	rows := 0
+	err := fn(func(cells []string, value any) error {
+		if err := w.Write(cells, value); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+			h.extendWriteDeadline(ctx)
+		}
+		return nil
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+
+	switch {
+	case err != nil && !ctx.Writer.Written():
+		h.HandleDatabaseLevelWithMessage(ctx, err, "streamExport: "+name)
+	case err != nil:
+		h.log.Ctx(ctx.Request.Context()).Error("streamExport: "+name+" cut short", err)
+	default:
+		out.start()
+	}
+}
+
+// extendWriteDeadline gives the client another HTTP_WRITE_TIMEOUT to take
+// the response, so the server timeout does not cut long exports short while
+// a stalled client still can not hold the connection forever.
+func (h *handlerV1) extendWriteDeadline(ctx *gin.Context) {
+	if h.cfg.HTTPWriteTimeout <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(time.Duration(h.cfg.HTTPWriteTimeout) * time.Second)
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(deadline); err != nil {
+		h.log.Ctx(ctx.Request.Context()).Error("extendWriteDeadline", err)
+	}
+}
+
+// attachmentWriter sets the attachment headers before the first write, so
+// the response is still JSON when it fails before.
+type attachmentWriter struct {
+	ctx         *gin.Context
+	contentType string
+	filename    string
+}
+
+func (w *attachmentWriter) start() {
+	if !w.ctx.Writer.Written() {
+		w.ctx.Header("Content-Type", w.contentType)
+		w.ctx.Header("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+		w.ctx.Status(http.StatusOK)
+		w.ctx.Writer.WriteHeaderNow()
+	}
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.ctx.Writer.Write(p)
+}
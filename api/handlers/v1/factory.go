@@ -1,9 +1,11 @@
 package v1
 
 import (
+	"github.com/casbin/casbin/v2"
 	t "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/tokens"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/otp"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
 )
@@ -14,6 +16,8 @@ type handlerV1 struct {
 	storage    storage.StorageI
 	jwthandler t.JWTHandler
 	redis      redisrepo.InMemoryStorageI
+	enforcer   *casbin.Enforcer
+	otp        otp.Service
 }
 
 type HandlerV1Config struct {
@@ -22,6 +26,7 @@ type HandlerV1Config struct {
 	Postgres   storage.StorageI
 	JWTHandler t.JWTHandler
 	Redis      redisrepo.InMemoryStorageI
+	Enforcer   *casbin.Enforcer
 }
 
 // New ...
@@ -32,5 +37,7 @@ func New(c *HandlerV1Config) *handlerV1 {
 		storage:    c.Postgres,
 		jwthandler: c.JWTHandler,
 		redis:      c.Redis,
+		enforcer:   c.Enforcer,
+		otp:        otp.New(c.Redis, c.Cfg),
 	}
 }
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/version"
+)
+
+// Healthz tells the process is alive, e.g. for a liveness probe. It does
+// not check dependencies, so an outage of them does not restart the
+// process.
+func (h *handlerV1) Healthz(ctx *gin.Context) {
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", nil)
+}
+
+// Readyz pings the dependencies concurrently, each within
+// HEALTH_CHECK_TIMEOUT, and responds 503 when one of them is unavailable,
+// e.g. for a readiness probe. Only ok or fail and the latency are responded
+// for each of them, why one failed is logged since it may reveal hosts or
+// addresses.
+func (h *handlerV1) Readyz(ctx *gin.Context) {
+	checks := map[string]func(context.Context) error{
+		"postgres": h.storage.Postgres().Ping,
+		"redis":    h.redis.Ping,
+	}
+
+	res := &models.ReadinessResponse{
+		Status: models.HealthStatusOk,
+		Checks: make(map[string]*models.HealthCheck, len(checks)),
+	}
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, ping := range checks {
+		wg.Add(1)
+		go func(name string, ping func(context.Context) error) {
+			defer wg.Done()
+			check := h.check(ctx.Request.Context(), name, ping)
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[name] = check
+			if check.Status != models.HealthStatusOk {
+				res.Status = models.HealthStatusUnavailable
+			}
+		}(name, ping)
+	}
+	wg.Wait()
+
+	if res.Status != models.HealthStatusOk {
+		h.HandleResponse(ctx, errors.New(ServiceUnavailable), http.StatusServiceUnavailable, ServiceUnavailable, "a dependency is unavailable", res)
+		return
+	}
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+func (h *handlerV1) check(ctx context.Context, name string, ping func(context.Context) error) *models.HealthCheck {
+	pingCtx, cancel := context.WithTimeout(ctx, time.Duration(h.cfg.HealthCheckTimeout)*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(pingCtx)
+	latency := time.Since(start)
+
+	res := &models.HealthCheck{
+		Status:    models.HealthStatusOk,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		h.log.Ctx(ctx).Warn("readiness check failed",
+			logger.String("check", name),
+			logger.Duration("latency_ms", latency),
+			err,
+		)
+		res.Status = models.HealthStatusFail
+	}
+	return res
+}
+
+// Version responds with the build info of the running program.
+func (h *handlerV1) Version(ctx *gin.Context) {
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", version.Get())
+}
@@ -1,12 +1,18 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	t "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/tokens"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/filter"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/requestid"
 	"github.com/spf13/cast"
 )
 
@@ -18,6 +24,67 @@ func ParsePageQueryParam(c *gin.Context) (int, error) {
 	return strconv.Atoi(c.DefaultQuery("page", "1"))
 }
 
+// ParseWithCountQueryParam tells whether the total count should be calculated.
+func ParseWithCountQueryParam(c *gin.Context, defaultValue bool) (bool, error) {
+	return strconv.ParseBool(c.DefaultQuery("with_count", strconv.FormatBool(defaultValue)))
+}
+
+// ParseFilterQueryParams parses filter[field][op]=value and sort params, allowing only given fields.
+func ParseFilterQueryParams(c *gin.Context, fields filter.Fields) (*filter.Query, error) {
+	return filter.Parse(c.Request.URL.Query(), fields)
+}
+
+// ETag returns the entity tag of the given version of a resource.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseIfMatchHeader returns the version the client expects to update.
+// It returns 0 when If-Match is absent or "*", so any version is updated.
+func ParseIfMatchHeader(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match header")
+	}
+	return version, nil
+}
+
+// IfNoneMatch reports whether If-None-Match contains etag, so the client's copy is fresh.
+func IfNoneMatch(c *gin.Context, etag string) bool {
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditContext returns the request context carrying who makes the request,
+// recorded in audit events by the storage.
+func AuditContext(h handlerV1, c *gin.Context) context.Context {
+	actor := audit.Actor{
+		Role:      "unauthorized",
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestId: requestid.FromContext(c.Request.Context()),
+	}
+
+	if c.GetHeader("Authorization") != "" {
+		if claims, err := GetClaims(h, c); err == nil {
+			actor.Sub = claims.Sub
+			actor.Role = claims.Role
+		}
+	}
+
+	return audit.WithActor(c.Request.Context(), actor)
+}
+
 func StructToStruct(from, to any) error {
 	body, err := json.Marshal(from)
 	if err != nil {
@@ -36,7 +103,7 @@ func GetClaims(h handlerV1, c *gin.Context) (*t.CustomClaims, error) {
 	token, err := jwt.Parse(strToken, func(t *jwt.Token) (interface{}, error) { return []byte(h.cfg.SignInKey), nil })
 
 	if err != nil {
-		h.log.Error("invalid access token")
+		h.log.Ctx(c.Request.Context()).Warn("invalid access token", err)
 		return nil, err
 	}
 	rawClaims := token.Claims.(jwt.MapClaims)
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// @Router		/template/import [POST]
+// @Summary		Import templates
+// @Tags        Template
+// @Description	Here templates can be created from a CSV or NDJSON file sent as the request body. CSV files need a header with a
+// @Description	template_name column and can have description, status, tags (comma separated), body and body_schema (JSON) columns,
+// @Description	other columns are ignored so exported files can be imported. NDJSON lines are objects like the TemplateCreate body.
+// @Description	The file is imported in the background, 202 is returned with the job which can be polled at /import/{id}.
+// @Description	Rows are validated like in TemplateCreate, invalid ones are reported by line and the others are still imported.
+// @Security    BearerAuth
+// @Accept      text/csv,application/x-ndjson
+// @Produce		json
+// @Param       format query    string false "csv or ndjson, taken from Content-Type when not given" Enums(csv,ndjson)
+// @Success		202 	{object}  models.ImportJobResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateImport(ctx *gin.Context) {
+	h.createImportJob(ctx, models.ImportKindTemplate)
+}
+
+// @Router		/user/import [POST]
+// @Summary		Import users
+// @Tags        User
+// @Description	Here admins can create users from a CSV file with user_name and email columns or an NDJSON file of
+// @Description	{"user_name": "...", "email": "..."} objects sent as the request body. Imported users have no password,
+// @Description	they set it with forgot password. The file is imported in the background, 202 is returned with the job
+// @Description	which can be polled at /import/{id}.
+// @Security    BearerAuth
+// @Accept      text/csv,application/x-ndjson
+// @Produce		json
+// @Param       format query    string false "csv or ndjson, taken from Content-Type when not given" Enums(csv,ndjson)
+// @Success		202 	{object}  models.ImportJobResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) UserImport(ctx *gin.Context) {
+	h.createImportJob(ctx, models.ImportKindUser)
+}
+
+// @Router		/import/{id} [GET]
+// @Summary		Get import job
+// @Tags        Import
+// @Description	Here who started an import can see how far it has got and which lines failed. status is succeeded when every
+// @Description	row was processed, even when some of them failed, and failed when the file could not be read at all.
+// @Security    BearerAuth
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Success		200 	{object}  models.ImportJobResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) ImportJobGet(ctx *gin.Context) {
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().ImportJobGet(ctx.Request.Context(), &models.ImportJobGetReq{
+		Id:       ctx.Param("id"),
+		OwnerSub: claim.Sub,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "ImportJobGet: h.storage.Postgres().ImportJobGet()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// createImportJob saves the request body as an import job of kind.
+func (h *handlerV1) createImportJob(ctx *gin.Context, kind string) {
+	format := ctx.Query("format")
+	if format == "" {
+		switch mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type")); mediaType {
+		case "application/x-ndjson", "application/jsonl":
+			format = models.FormatNDJSON
+		default:
+			format = models.FormatCSV
+		}
+	}
+	format, ok := h.parseFormat(ctx, format)
+	if !ok {
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, int64(h.cfg.ImportMaxSize)<<20))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		h.HandleResponse(ctx, err, http.StatusRequestEntityTooLarge, SizeExceeded, fmt.Sprintf("File size should be less than %d mb", h.cfg.ImportMaxSize), nil)
+		return
+	}
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
+		return
+	}
+	if len(payload) == 0 {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "file is empty", nil)
+		return
+	}
+
+	res, err := h.storage.Postgres().ImportJobCreate(ctx.Request.Context(), &models.ImportJobCreateReq{
+		Kind:      kind,
+		Format:    format,
+		OwnerSub:  claim.Sub,
+		OwnerRole: claim.Role,
+		Payload:   payload,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "createImportJob: h.storage.Postgres().ImportJobCreate()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusAccepted, Success, "", res)
+}
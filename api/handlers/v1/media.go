@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/metrics"
 	"github.com/google/uuid"
 )
 
@@ -46,6 +47,8 @@ func (h *handlerV1) UploadMedia(ctx *gin.Context) {
 	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UploadMedia:SaveUploadedFile", nil) {
 		return
 	}
+	metrics.MediaUploads.Inc()
+	metrics.MediaUploadedBytes.Add(float64(file.File.Size))
 
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", models.UploadPhotoRes{
 		URL: h.cfg.BaseUrl + "media/" + file.File.Filename,
@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// @Router		/db/query-stats [GET]
+// @Summary		Get query stats
+// @Tags        Database
+// @Description	Here admins can see how many times each query ran on this instance since it started, how often it failed and how long it took.
+// @Security    BearerAuth
+// @Produce		json
+// @Success		200 	{object}  models.QueryStatsResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) QueryStats(ctx *gin.Context) {
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", &models.QueryStatsResponse{
+		SlowQueryThresholdMs: h.cfg.PostgresSlowQueryThreshold,
+		Queries:              h.storage.QueryStats(),
+	})
+}
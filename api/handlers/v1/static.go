@@ -20,9 +20,24 @@ const (
 	// 404
 	NotFound = "not_found"
 
+	// 409
+	Conflict = "conflict"
+
+	// 412
+	PreconditionFailed = "precondition_failed"
+
 	// 413
 	SizeExceeded = "size_exceeded"
 
+	// 422
+	IdempotencyKeyReused = "idempotency_key_reused"
+
+	// 429
+	TooManyRequests = "too_many_requests"
+
 	// 500
 	InternalServerError = "internal_server_error"
+
+	// 503
+	ServiceUnavailable = "service_unavailable"
 )
\ No newline at end of file
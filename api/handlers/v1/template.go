@@ -1,7 +1,7 @@
 package v1
 
 import (
-	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -12,7 +12,8 @@ import (
 // @Router		/template [POST]
 // @Summary		Create template
 // @Tags        Template
-// @Description	Here template can be created.
+// @Description	Here template can be created. When body_schema is given, body should match it,
+// @Description	otherwise 400 is returned with the violations in data as [{"field": "body.title", "message": "..."}].
 // @Security    BearerAuth
 // @Accept      json
 // @Produce		json
@@ -26,7 +27,18 @@ func (h *handlerV1) TemplateCreate(ctx *gin.Context) {
 		return
 	}
 
-	res, err := h.storage.Postgres().TemplateCreate(context.Background(), body)
+	if fieldErrors := body.TemplateContent.Validate(); len(fieldErrors) > 0 {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "invalid body", fieldErrors)
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+	body.OwnerSub = claim.Sub
+
+	res, err := h.storage.Postgres().TemplateCreate(AuditContext(*h, ctx), body)
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateCreate: h.storage.Postgres().TemplateCreate()") {
 		return
 	}
@@ -37,29 +49,52 @@ func (h *handlerV1) TemplateCreate(ctx *gin.Context) {
 // @Router		/template/{id} [GET]
 // @Summary		Get template by key
 // @Tags        Template
-// @Description	Here template can be got.
+// @Description	Here template can be got by its owner, its collaborators and users with a role allowed to read all templates.
+// @Description	Templates created before owners existed can be got by anyone.
 // @Security    BearerAuth
 // @Accept      json
 // @Produce		json
 // @Param       id       path     int true "id"
+// @Param       If-None-Match header string false "ETag of the cached copy, 304 is returned when it is still current"
 // @Success		200 	{object}  models.TemplateResponse
+// @Success		304
 // @Failure     default {object}  models.StandardResponse
 func (h *handlerV1) TemplateGet(ctx *gin.Context) {
-	res, err := h.storage.Postgres().TemplateGet(context.Background(), &models.TemplateGetReq{
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateRead) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateGet(ctx.Request.Context(), &models.TemplateGetReq{
 		Id: ctx.Param("id"),
 	})
-	
+
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateGet: h.storage.Postgres().TemplateGet()") {
 		return
 	}
 
+	etag := ETag(res.Version)
+	ctx.Header("ETag", etag)
+	if IfNoneMatch(ctx, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
 }
 
 // @Router		/template/list [GET]
 // @Summary		Get templates list
 // @Tags        Template
-// @Description	Here all templates can be got.
+// @Description	Here all templates can be got. Pass cursor (empty for the first page) to paginate by next_cursor/prev_cursor instead of page.
+// @Description	The total count is calculated for page requests by default and for cursor requests only when with_count=true.
+// @Description	Filter with filter[field][op]=value, where op is one of eq, ne, gt, gte, lt, lte, in, like, prefix, null (eq when omitted),
+// @Description	and sort with sort=-updated_at,template_name. Filterable fields: id, template_name, status, created_at, updated_at.
+// @Description	tag=a&tag=b returns templates having all of the tags. body and body_schema are not returned in the list.
+// @Description	search_mode=fulltext matches whole words anywhere in the name with stemming, ranks results and returns highlighted snippets,
+// @Description	names with typos are matched by similarity. The default prefix mode matches names starting with search.
+// @Description	order_by_created_at=1 sorts newest first and -1 oldest first, it is ignored when sort is given.
+// @Description	Cursor pages are always newest first, so cursor can not be combined with search_mode=fulltext or order_by_created_at=-1,
+// @Description	and a cursor is only valid with the filters, tags and search it was returned for.
 // @Security    BearerAuth
 // @Accept      json
 // @Produce		json
@@ -82,10 +117,22 @@ func (h *handlerV1) TemplateFind(ctx *gin.Context) {
 		return
 	}
 
-	dbReq.Search = ctx.Query("search")
-	dbReq.OrderByCreatedAt, _ = strconv.ParseUint(ctx.Query("order_by_created_at"), 10, 8)
+	dbReq.Cursor, dbReq.Keyset = ctx.GetQuery("cursor")
+	dbReq.WithCount, err = ParseWithCountQueryParam(ctx, !dbReq.Keyset)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid with_count param", nil) {
+		return
+	}
 
-	res, err := h.storage.Postgres().TemplateFind(context.Background(), dbReq)
+	if !h.parseTemplateFilters(ctx, dbReq) {
+		return
+	}
+	// cursor pages are ordered newest first, which neither can be
+	if dbReq.Keyset && (dbReq.SearchMode == models.SearchModeFullText || dbReq.OrderByCreatedAt < 0) {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "cursor can not be combined with search_mode=fulltext or order_by_created_at=-1", nil)
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateFind(ctx.Request.Context(), dbReq)
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateFind: h.storage.Postgres().TemplateFind()") {
 		return
 	}
@@ -96,11 +143,12 @@ func (h *handlerV1) TemplateFind(ctx *gin.Context) {
 // @Router		/template [PUT]
 // @Summary		Update template
 // @Tags        Template
-// @Description	Here template can be updated.
+// @Description	Here template can be updated by its owner and editors, all of its content is replaced. body is validated like in TemplateCreate.
 // @Security    BearerAuth
 // @Accept      json
 // @Produce		json
 // @Param       post   body       models.TemplateUpdateReq true "post info"
+// @Param       If-Match header string false "ETag from TemplateGet, 412 is returned when the template was changed since"
 // @Success		200 	{object}  models.TemplateResponse
 // @Failure     default {object}  models.StandardResponse
 func (h *handlerV1) TemplateUpdate(ctx *gin.Context) {
@@ -110,18 +158,33 @@ func (h *handlerV1) TemplateUpdate(ctx *gin.Context) {
 		return
 	}
 
-	res, err := h.storage.Postgres().TemplateUpdate(context.Background(), body)
+	if fieldErrors := body.TemplateContent.Validate(); len(fieldErrors) > 0 {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "invalid body", fieldErrors)
+		return
+	}
+
+	body.Version, err = ParseIfMatchHeader(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid If-Match header", nil) {
+		return
+	}
+
+	if !h.authorizeTemplate(ctx, body.Id, templateEdit) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateUpdate(AuditContext(*h, ctx), body)
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateUpdate: h.storage.Postgres().TemplateUpdate()") {
 		return
 	}
 
+	ctx.Header("ETag", ETag(res.Version))
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
 }
 
 // @Router		/template/{id} [DELETE]
 // @Summary		Delete template
 // @Tags        Template
-// @Description	Here template can be deleted.
+// @Description	Here template can be deleted by its owner.
 // @Security    BearerAuth
 // @Accept      json
 // @Produce		json
@@ -129,10 +192,44 @@ func (h *handlerV1) TemplateUpdate(ctx *gin.Context) {
 // @Success		200 	{object}  models.StandardResponse
 // @Failure     default {object}  models.StandardResponse
 func (h *handlerV1) TemplateDelete(ctx *gin.Context) {
-	err := h.storage.Postgres().TemplateDelete(context.Background(), &models.TemplateDeleteReq{Id: ctx.Param("id")})
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateDelete) {
+		return
+	}
+
+	err := h.storage.Postgres().TemplateDelete(AuditContext(*h, ctx), &models.TemplateDeleteReq{Id: ctx.Param("id")})
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateDelete: h.storage.Postgres().TemplateDelete()") {
 		return
 	}
 
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "Successfully deleted", nil)
 }
+
+// parseTemplateFilters reads which templates to find from the query,
+// writing the error response when it is invalid.
+func (h *handlerV1) parseTemplateFilters(ctx *gin.Context, dbReq *models.TemplateFindReq) bool {
+	var err error
+
+	dbReq.Filter, err = ParseFilterQueryParams(ctx, models.TemplateFields)
+	if err != nil {
+		h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, err.Error(), nil)
+		return false
+	}
+
+	dbReq.OrderByCreatedAt, err = strconv.Atoi(ctx.DefaultQuery("order_by_created_at", "0"))
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid order_by_created_at param", nil) {
+		return false
+	}
+
+	dbReq.Search = ctx.Query("search")
+	dbReq.SearchMode = ctx.DefaultQuery("search_mode", models.SearchModePrefix)
+	if dbReq.SearchMode != models.SearchModePrefix && dbReq.SearchMode != models.SearchModeFullText {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "invalid search_mode param", nil)
+		return false
+	}
+	dbReq.Sort = ctx.Query("sort")
+	dbReq.Tags = models.NormalizeTags(ctx.QueryArray("tag"))
+
+	var ok bool
+	dbReq.VisibleTo, ok = h.templateVisibleTo(ctx)
+	return ok
+}
@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// templateBulkLimit is how many operations one bulk request can have.
+const templateBulkLimit = 1000
+
+// @Router		/template/bulk [POST]
+// @Summary		Create, update and delete templates in bulk
+// @Tags        Template
+// @Description	Here up to 1000 templates can be created, updated and deleted with one request. In atomic mode all of the operations
+// @Description	are applied or none of them, in best_effort mode every operation is applied on its own. Operations are validated and
+// @Description	authorized like the single template endpoints, version works like If-Match of them. 200 is returned with a result for
+// @Description	every operation, failed ones have the http_status, code and message the single template endpoint would respond with.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       post   body       models.TemplateBulkReq true "operations"
+// @Success		200 	{object}  models.TemplateBulkResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateBulk(ctx *gin.Context) {
+	body := &models.TemplateBulkReq{}
+	err := ctx.ShouldBindJSON(&body)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
+		return
+	}
+
+	switch body.Mode {
+	case "":
+		body.Mode = models.BulkModeAtomic
+	case models.BulkModeAtomic, models.BulkModeBestEffort:
+	default:
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "mode should be one of atomic, best_effort", nil)
+		return
+	}
+
+	if len(body.Operations) == 0 || len(body.Operations) > templateBulkLimit {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, fmt.Sprintf("operations should have 1 to %d items", templateBulkLimit), nil)
+		return
+	}
+
+	role, sub, ok := h.requester(ctx)
+	if !ok {
+		return
+	}
+	body.OwnerSub = sub
+
+	var (
+		res      = &models.TemplateBulkResponse{Mode: body.Mode, Results: make([]*models.TemplateBulkResult, len(body.Operations))}
+		accepted []*models.TemplateBulkOperation
+	)
+	for i, op := range body.Operations {
+		if op == nil {
+			op = &models.TemplateBulkOperation{}
+		}
+		op.Index = i
+		res.Results[i] = &models.TemplateBulkResult{Index: i, Op: op.Op, Id: op.Id, Status: models.BulkStatusAborted}
+
+		if h.checkTemplateBulkOperation(ctx, role, sub, op, res.Results[i]) {
+			accepted = append(accepted, op)
+		}
+	}
+
+	rejected := len(accepted) < len(body.Operations)
+	if len(accepted) > 0 && !(rejected && body.Mode == models.BulkModeAtomic) {
+		results, err := h.storage.Postgres().TemplateBulk(AuditContext(*h, ctx), &models.TemplateBulkReq{
+			Mode:       body.Mode,
+			Operations: accepted,
+			OwnerSub:   body.OwnerSub,
+		})
+		if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateBulk: h.storage.Postgres().TemplateBulk()") {
+			return
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				result.HttpStatus, result.Code, result.Message = databaseErrorStatus(result.Err)
+			}
+			res.Results[result.Index] = result
+		}
+	}
+
+	for _, result := range res.Results {
+		switch result.Status {
+		case models.BulkStatusSucceeded:
+			res.Succeeded++
+		case models.BulkStatusFailed:
+			res.Failed++
+		}
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// checkTemplateBulkOperation validates and authorizes op like the single
+// template endpoints do, recording why it can not be applied in res.
+func (h *handlerV1) checkTemplateBulkOperation(ctx *gin.Context, role, sub string, op *models.TemplateBulkOperation, res *models.TemplateBulkResult) bool {
+	reject := func(httpStatus int, code, message string, fieldErrors []models.FieldError) bool {
+		res.Status = models.BulkStatusFailed
+		res.HttpStatus, res.Code, res.Message, res.Errors = httpStatus, code, message, fieldErrors
+		return false
+	}
+
+	var action string
+	switch op.Op {
+	case models.BulkOpCreate:
+	case models.BulkOpUpdate:
+		action = templateEdit
+	case models.BulkOpDelete:
+		action = templateDelete
+	default:
+		return reject(http.StatusBadRequest, BadRequest, "op should be one of create, update, delete", nil)
+	}
+
+	if op.Op != models.BulkOpDelete {
+		if fieldErrors := op.TemplateContent.Validate(); len(fieldErrors) > 0 {
+			return reject(http.StatusBadRequest, BadRequest, "invalid body", fieldErrors)
+		}
+	}
+
+	if action == "" {
+		return true
+	}
+	if op.Id == "" {
+		return reject(http.StatusBadRequest, BadRequest, "id is required", nil)
+	}
+
+	allowed, err := h.enforcer.Enforce(role, "template:"+op.Id, action)
+	if err != nil {
+		h.log.Ctx(ctx.Request.Context()).Error("checkTemplateBulkOperation: h.enforcer.Enforce()", err)
+		return reject(http.StatusInternalServerError, InternalServerError, "Internal server error", nil)
+	}
+	if allowed {
+		return true
+	}
+
+	access, err := h.storage.Postgres().TemplateAccess(ctx.Request.Context(), &models.TemplateAccessReq{
+		TemplateId: op.Id,
+		UserId:     sub,
+	})
+	if err != nil {
+		httpStatus, code, message := databaseErrorStatus(err)
+		return reject(httpStatus, code, message, nil)
+	}
+
+	if !templateRoleAllows(access, sub, action) {
+		return reject(http.StatusForbidden, PermissionDenied, "you have no access to this template", nil)
+	}
+	return true
+}
@@ -0,0 +1,323 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+)
+
+// Actions on a template checked by authorizeTemplate.
+const (
+	templateRead   = "read"
+	templateEdit   = "edit"
+	templateDelete = "delete"
+	templateShare  = "share" // manage collaborators and share links
+)
+
+// authorizeTemplate tells whether the requester may perform action on the
+// template, writing the error response when not. Roles with a Casbin policy
+// like "p, admin, template:*, (read|edit)" may act on every template, others
+// need to own it or to be granted a role on it.
+func (h *handlerV1) authorizeTemplate(ctx *gin.Context, templateId, action string) bool {
+	role, sub, ok := h.requester(ctx)
+	if !ok {
+		return false
+	}
+
+	allowed, err := h.enforcer.Enforce(role, "template:"+templateId, action)
+	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "authorizeTemplate: h.enforcer.Enforce()", nil) {
+		return false
+	}
+	if allowed {
+		return true
+	}
+
+	access, err := h.storage.Postgres().TemplateAccess(ctx.Request.Context(), &models.TemplateAccessReq{
+		TemplateId: templateId,
+		UserId:     sub,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "authorizeTemplate: h.storage.Postgres().TemplateAccess()") {
+		return false
+	}
+
+	if !templateRoleAllows(access, sub, action) {
+		h.HandleResponse(ctx, fmt.Errorf(PermissionDenied), http.StatusForbidden, PermissionDenied, "you have no access to this template", nil)
+		return false
+	}
+	return true
+}
+
+// templateVisibleTo returns whose templates TemplateFind should return, nil
+// when the requester can read all of them.
+func (h *handlerV1) templateVisibleTo(ctx *gin.Context) (*string, bool) {
+	role, sub, ok := h.requester(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	allowed, err := h.enforcer.Enforce(role, "template:*", templateRead)
+	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "templateVisibleTo: h.enforcer.Enforce()", nil) {
+		return nil, false
+	}
+	if allowed {
+		return nil, true
+	}
+	return &sub, true
+}
+
+// requester returns the role and the id of who makes the request, writing
+// the error response when the token is invalid.
+func (h *handlerV1) requester(ctx *gin.Context) (role, sub string, ok bool) {
+	if ctx.GetHeader("Authorization") == "" {
+		return "unauthorized", "", true
+	}
+
+	claims, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return "", "", false
+	}
+	return claims.Role, claims.Sub, true
+}
+
+func templateRoleAllows(access *models.TemplateAccessResponse, sub, action string) bool {
+	if access.OwnerSub == "" {
+		// templates created before owners existed work as they used to
+		return action == templateRead || sub != "" && (action == templateEdit || action == templateDelete)
+	}
+
+	switch access.Role {
+	case models.TemplateRoleOwner:
+		return true
+	case models.TemplateRoleEditor:
+		return action == templateRead || action == templateEdit
+	case models.TemplateRoleViewer:
+		return action == templateRead
+	}
+	return false
+}
+
+// @Router		/template/{id}/collaborators [GET]
+// @Summary		Get template collaborators
+// @Tags        Template sharing
+// @Description	Here the owner can see who has access to the template. Viewers can read the template, editors can also update and restore it.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Success		200 	{object}  models.TemplateCollaboratorFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateGrantFind(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateShare) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateGrantFind(ctx.Request.Context(), &models.TemplateGrantFindReq{
+		TemplateId: ctx.Param("id"),
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateGrantFind: h.storage.Postgres().TemplateGrantFind()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/collaborators [POST]
+// @Summary		Grant template access
+// @Tags        Template sharing
+// @Description	Here the owner can give a user the viewer or editor role on the template, replacing the role the user had.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       post   body       models.TemplateGrantReq true "grant"
+// @Success		200 	{object}  models.TemplateCollaboratorResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateGrant(ctx *gin.Context) {
+	body := &models.TemplateGrantReq{}
+	err := ctx.ShouldBindJSON(&body)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
+		return
+	}
+	body.TemplateId = ctx.Param("id")
+
+	if body.Role != models.TemplateRoleViewer && body.Role != models.TemplateRoleEditor {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "role should be viewer or editor", nil)
+		return
+	}
+
+	if !h.authorizeTemplate(ctx, body.TemplateId, templateShare) {
+		return
+	}
+
+	template, err := h.storage.Postgres().TemplateGet(ctx.Request.Context(), &models.TemplateGetReq{Id: body.TemplateId})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateGrant: h.storage.Postgres().TemplateGet()") {
+		return
+	}
+	if template.OwnerSub == body.UserId {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "the owner already has full access", nil)
+		return
+	}
+
+	_, err = h.storage.Postgres().UserGet(ctx.Request.Context(), &models.UserGetReq{Id: body.UserId})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateGrant: h.storage.Postgres().UserGet()") {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateGrant(AuditContext(*h, ctx), body)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateGrant: h.storage.Postgres().TemplateGrant()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/collaborators/{user_id} [DELETE]
+// @Summary		Revoke template access
+// @Tags        Template sharing
+// @Description	Here the owner can take the role of a user on the template away.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       user_id  path     string true "user id"
+// @Success		200 	{object}  models.StandardResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateGrantDelete(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateShare) {
+		return
+	}
+
+	err := h.storage.Postgres().TemplateGrantDelete(AuditContext(*h, ctx), &models.TemplateGrantDeleteReq{
+		TemplateId: ctx.Param("id"),
+		UserId:     ctx.Param("user_id"),
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateGrantDelete: h.storage.Postgres().TemplateGrantDelete()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "Successfully revoked", nil)
+}
+
+// @Router		/template/{id}/share-links [POST]
+// @Summary		Create template share link
+// @Tags        Template sharing
+// @Description	Here the owner can create a link anyone can read the template with until expires_at (RFC3339, never when empty).
+// @Description	The url is returned only here.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       post   body       models.TemplateShareLinkCreateReq true "share link"
+// @Success		200 	{object}  models.TemplateShareLinkResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateShareLinkCreate(ctx *gin.Context) {
+	body := &models.TemplateShareLinkCreateReq{}
+	err := ctx.ShouldBindJSON(&body)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
+		return
+	}
+	body.TemplateId = ctx.Param("id")
+
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "expires_at should be in the future", nil)
+		return
+	}
+
+	if !h.authorizeTemplate(ctx, body.TemplateId, templateShare) {
+		return
+	}
+
+	token, err := etc.GenerateToken()
+	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "TemplateShareLinkCreate: etc.GenerateToken()", nil) {
+		return
+	}
+	body.TokenHash = etc.HashToken(token)
+	if body.ExpiresAt != nil {
+		expiresAt := body.ExpiresAt.UTC()
+		body.ExpiresAt = &expiresAt
+	}
+
+	res, err := h.storage.Postgres().TemplateShareLinkCreate(AuditContext(*h, ctx), body)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateShareLinkCreate: h.storage.Postgres().TemplateShareLinkCreate()") {
+		return
+	}
+	res.Url = h.cfg.BaseUrl + "template/shared/" + token
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/share-links [GET]
+// @Summary		Get template share links
+// @Tags        Template sharing
+// @Description	Here the owner can see the share links of the template, without their urls.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Success		200 	{object}  models.TemplateShareLinkFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateShareLinkFind(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateShare) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateShareLinkFind(ctx.Request.Context(), &models.TemplateShareLinkFindReq{
+		TemplateId: ctx.Param("id"),
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateShareLinkFind: h.storage.Postgres().TemplateShareLinkFind()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/share-links/{link_id} [DELETE]
+// @Summary		Delete template share link
+// @Tags        Template sharing
+// @Description	Here the owner can revoke a share link, it stops working right away.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       link_id  path     string true "share link id"
+// @Success		200 	{object}  models.StandardResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateShareLinkDelete(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateShare) {
+		return
+	}
+
+	err := h.storage.Postgres().TemplateShareLinkDelete(AuditContext(*h, ctx), &models.TemplateShareLinkDeleteReq{
+		TemplateId: ctx.Param("id"),
+		Id:         ctx.Param("link_id"),
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateShareLinkDelete: h.storage.Postgres().TemplateShareLinkDelete()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "Successfully deleted", nil)
+}
+
+// @Router		/template/shared/{token} [GET]
+// @Summary		Get shared template
+// @Tags        Template sharing
+// @Description	Here anyone with a share link can read the template until the link expires or is deleted.
+// @Accept      json
+// @Produce		json
+// @Param       token    path     string true "token of the share link"
+// @Success		200 	{object}  models.TemplateResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateSharedGet(ctx *gin.Context) {
+	res, err := h.storage.Postgres().TemplateSharedGet(ctx.Request.Context(), &models.TemplateSharedGetReq{
+		TokenHash: etc.HashToken(ctx.Param("token")),
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateSharedGet: h.storage.Postgres().TemplateSharedGet()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
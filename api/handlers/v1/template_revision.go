@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// @Router		/template/{id}/revisions [GET]
+// @Summary		Get template revisions
+// @Tags        Template
+// @Description	Here the revisions of a template can be got, newest first. Every create, update and restore saves a revision
+// @Description	numbered by the version it produced.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       filters query models.TemplateRevisionFindReq true "filters"
+// @Success		200 	{object}  models.TemplateRevisionFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateRevisionFind(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateRead) {
+		return
+	}
+
+	var (
+		dbReq = &models.TemplateRevisionFindReq{TemplateId: ctx.Param("id")}
+		err   error
+	)
+
+	dbReq.Page, err = ParsePageQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid page param", nil) {
+		return
+	}
+
+	dbReq.Limit, err = ParseLimitQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid limit param", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateRevisionFind(ctx.Request.Context(), dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateRevisionFind: h.storage.Postgres().TemplateRevisionFind()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/revisions/{revision} [GET]
+// @Summary		Get template revision
+// @Tags        Template
+// @Description	Here the template can be got as it was saved at a revision.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       revision path     int true "revision"
+// @Success		200 	{object}  models.TemplateRevisionResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateRevisionGet(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateRead) {
+		return
+	}
+
+	revision, err := strconv.Atoi(ctx.Param("revision"))
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid revision param", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateRevisionGet(ctx.Request.Context(), &models.TemplateRevisionGetReq{
+		TemplateId: ctx.Param("id"),
+		Revision:   revision,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateRevisionGet: h.storage.Postgres().TemplateRevisionGet()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/revisions/diff [GET]
+// @Summary		Diff template revisions
+// @Tags        Template
+// @Description	Here the fields changed from revision from to revision to can be got with their before and after values.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       filters query models.TemplateRevisionDiffReq true "revisions"
+// @Success		200 	{object}  models.TemplateRevisionDiffResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateRevisionDiff(ctx *gin.Context) {
+	if !h.authorizeTemplate(ctx, ctx.Param("id"), templateRead) {
+		return
+	}
+
+	var (
+		dbReq = &models.TemplateRevisionDiffReq{TemplateId: ctx.Param("id")}
+		err   error
+	)
+
+	dbReq.From, err = strconv.Atoi(ctx.Query("from"))
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid from param", nil) {
+		return
+	}
+
+	dbReq.To, err = strconv.Atoi(ctx.Query("to"))
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid to param", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateRevisionDiff(ctx.Request.Context(), dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateRevisionDiff: h.storage.Postgres().TemplateRevisionDiff()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/template/{id}/revisions/{revision}/restore [POST]
+// @Summary		Restore template revision
+// @Tags        Template
+// @Description	Here the content of an old revision can be saved as the new version of the template.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Param       revision path     int true "revision"
+// @Param       If-Match header string false "ETag from TemplateGet, 412 is returned when the template was changed since"
+// @Success		200 	{object}  models.TemplateResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) TemplateRestore(ctx *gin.Context) {
+	var (
+		dbReq = &models.TemplateRestoreReq{TemplateId: ctx.Param("id")}
+		err   error
+	)
+
+	dbReq.Revision, err = strconv.Atoi(ctx.Param("revision"))
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid revision param", nil) {
+		return
+	}
+
+	dbReq.Version, err = ParseIfMatchHeader(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid If-Match header", nil) {
+		return
+	}
+
+	if !h.authorizeTemplate(ctx, dbReq.TemplateId, templateEdit) {
+		return
+	}
+
+	res, err := h.storage.Postgres().TemplateRestore(AuditContext(*h, ctx), dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "TemplateRestore: h.storage.Postgres().TemplateRestore()") {
+		return
+	}
+
+	ctx.Header("ETag", ETag(res.Version))
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
@@ -2,7 +2,7 @@ package v1
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,10 +11,12 @@ import (
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/helper/email"
 	token "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/tokens"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/metrics"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/otp"
 	"github.com/golanguzb70/validator"
 	"github.com/google/uuid"
-	"github.com/spf13/cast"
 )
 
 // @Router		/user/check/{email} [GET]
@@ -31,7 +33,7 @@ func (h *handlerV1) UserCheck(ctx *gin.Context) {
 		emailP = ctx.Param("email")
 	)
 
-	ctxTimout, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	ctxTimout, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*time.Duration(h.cfg.ContextTimeout))
 	defer cancel()
 
 	exists, err := h.storage.Postgres().CheckIfExists(ctxTimout, &models.CheckIfExistsReq{
@@ -50,26 +52,23 @@ func (h *handlerV1) UserCheck(ctx *gin.Context) {
 		return
 	}
 
-	otp := &models.Otp{
-		Email: emailP,
-		Code:  etc.GenerateCode(6),
-	}
-	// save to redis
-	otpByte, err := json.Marshal(otp)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserCheck: json.Marshal(otp)", nil) {
-		return
-	}
-
-	err = h.redis.SetWithTTL(emailP, string(otpByte), int(h.cfg.OtpTimeout))
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserCheck: redis.SetWithTTL()", nil) {
+	code, err := h.otp.Issue(ctxTimout, otp.PurposeRegister, emailP)
+	if h.HandleOtpError(ctx, err, "UserCheck: h.otp.Issue()") {
 		return
 	}
 
 	// send otp email
-	err = email.SendEmail([]string{emailP}, "GolangUzb70\n", h.cfg, "./api/helper/email/emailotp.html", otp)
+	err = email.SendEmail(ctxTimout, []string{emailP}, "GolangUzb70\n", h.cfg, "./api/helper/email/emailotp.html", &models.Otp{
+		Email: emailP,
+		Code:  code,
+	})
+	if err != nil {
+		h.revokeOtp(ctx, otp.PurposeRegister, emailP)
+	}
 	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserCheck: email.SendEmail()", nil) {
 		return
 	}
+	metrics.OtpsSent.WithLabelValues(otp.PurposeRegister).Inc()
 
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", &models.UserCheckRes{
 		Status: "register",
@@ -88,30 +87,18 @@ func (h *handlerV1) UserCheck(ctx *gin.Context) {
 // @Failure     default {object}  models.StandardResponse
 func (h *handlerV1) OtpCheck(ctx *gin.Context) {
 	var (
-		body   models.Otp
 		emailP = ctx.Query("email")
-		otp    = ctx.Query("otp")
+		code   = ctx.Query("otp")
 	)
 
-	otpAny, err := h.redis.Get(emailP)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "OtpCheck: json.redis.Get()", nil) {
-		return
-	}
-
-	if otpAny == "" {
-		if h.HandleResponse(ctx, fmt.Errorf(NotFound), http.StatusBadRequest, NotFound, "otp expired", nil) {
-			return
-		}
-	}
-
-	err = json.Unmarshal([]byte(cast.ToString(otpAny)), &body)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "OtpCheck: json.Unmarshal()", nil) {
+	err := h.otp.Check(ctx.Request.Context(), otp.PurposeRegister, emailP, code)
+	if !errors.Is(err, otp.ErrIncorrect) && h.HandleOtpError(ctx, err, "OtpCheck: h.otp.Check()") {
 		return
 	}
 
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", struct {
 		IsRight bool `json:"is_right"`
-	}{IsRight: body.Code == otp})
+	}{IsRight: err == nil})
 }
 
 // @Router		/user [POST]
@@ -128,30 +115,14 @@ func (h *handlerV1) UserRegister(ctx *gin.Context) {
 		res = &models.UserResponse{}
 	)
 	body := &models.UserRegisterReq{}
-	otpBody := models.Otp{}
 
 	err := ctx.ShouldBindJSON(&body)
 	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
 		return
 	}
 
-	otpAny, err := h.redis.Get(body.Email)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserRegister: redis.Get()", nil) {
-		return
-	}
-
-	if cast.ToString(otpAny) == "" {
-		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "otp expired", nil)
-		return
-	}
-
-	err = json.Unmarshal([]byte(cast.ToString(otpAny)), &otpBody)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserRegister: json.Unmarshal()", nil) {
-		return
-	}
-
-	if otpBody.Code != body.Otp {
-		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "otp incorrect", nil)
+	err = h.otp.Verify(ctx.Request.Context(), otp.PurposeRegister, body.Email, body.Otp)
+	if h.HandleOtpError(ctx, err, "UserRegister: h.otp.Verify()") {
 		return
 	}
 
@@ -181,7 +152,7 @@ func (h *handlerV1) UserRegister(ctx *gin.Context) {
 		return
 	}
 
-	ctxWithCancel, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	ctxWithCancel, cancel := context.WithTimeout(AuditContext(*h, ctx), time.Second*time.Duration(h.cfg.ContextTimeout))
 	defer cancel()
 
 	req.RefreshToken = refresh
@@ -189,6 +160,7 @@ func (h *handlerV1) UserRegister(ctx *gin.Context) {
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "UserRegister: h.storage.Postgres().UserCreate()") {
 		return
 	}
+	metrics.Registrations.Inc()
 
 	res.AccessToken = access
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
@@ -219,7 +191,7 @@ func (h *handlerV1) LoginUser(ctx *gin.Context) {
 	} else {
 		req.UserName = body.UserNameOrEmail
 	}
-	ctxWithCancel, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	ctxWithCancel, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*time.Duration(h.cfg.ContextTimeout))
 	defer cancel()
 
 	res, err := h.storage.Postgres().UserGet(ctxWithCancel, &req)
@@ -227,14 +199,37 @@ func (h *handlerV1) LoginUser(ctx *gin.Context) {
 		return
 	}
 
+	actor := audit.ActorFrom(AuditContext(*h, ctx))
+	actor.Sub, actor.Role = res.Id, res.Role
+	auditCtx := audit.WithActor(ctxWithCancel, actor)
+
 	if !etc.CheckPasswordHash(body.Password, res.Password) {
+		metrics.Logins.WithLabelValues(metrics.LoginFailure).Inc()
+		err = h.storage.Postgres().AuditEventCreate(auditCtx, &models.AuditEventCreateReq{
+			Action:       audit.ActionUserLoginFailed,
+			ResourceType: "user",
+			ResourceId:   res.Id,
+		})
+		if h.HandleDatabaseLevelWithMessage(ctx, err, "LoginUser: h.storage.Postgres().AuditEventCreate()") {
+			return
+		}
+
 		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "incorrect password", nil)
 		return
 	}
 
+	err = h.storage.Postgres().AuditEventCreate(auditCtx, &models.AuditEventCreateReq{
+		Action:       audit.ActionUserLogin,
+		ResourceType: "user",
+		ResourceId:   res.Id,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "LoginUser: h.storage.Postgres().AuditEventCreate()") {
+		return
+	}
+
 	h.jwthandler = token.JWTHandler{
 		Sub:       res.Id,
-		Role:      "user",
+		Role:      res.Role,
 		SigninKey: h.cfg.SignInKey,
 		Aud:       []string{"template-front"},
 		Log:       h.log,
@@ -244,6 +239,7 @@ func (h *handlerV1) LoginUser(ctx *gin.Context) {
 	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "LoginUser: jwthandler.GenerateAuthJWT()", nil) {
 		return
 	}
+	metrics.Logins.WithLabelValues(metrics.LoginSuccess).Inc()
 
 	res.AccessToken = access
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
@@ -271,7 +267,7 @@ func (h *handlerV1) UserForgotPassword(ctx *gin.Context) {
 	} else {
 		req.UserName = uOre
 	}
-	ctxWithCancel, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	ctxWithCancel, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*time.Duration(h.cfg.ContextTimeout))
 	defer cancel()
 
 	res, err := h.storage.Postgres().UserGet(ctxWithCancel, &req)
@@ -279,27 +275,23 @@ func (h *handlerV1) UserForgotPassword(ctx *gin.Context) {
 		return
 	}
 
-	otp := &models.Otp{
-		Email: res.Email,
-		Code:  etc.GenerateCode(6),
-	}
-
-	// save to redis
-	otpByte, err := json.Marshal(otp)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserForgotPassword: json.Marshal(otp)", nil) {
-		return
-	}
-
-	err = h.redis.SetWithTTL(res.Email, string(otpByte), int(h.cfg.OtpTimeout))
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserForgotPassword: redis.SetWithTTL()", nil) {
+	code, err := h.otp.Issue(ctxWithCancel, otp.PurposeForgotPassword, res.Email)
+	if h.HandleOtpError(ctx, err, "UserForgotPassword: h.otp.Issue()") {
 		return
 	}
 
 	// send otp email
-	err = email.SendEmail([]string{res.Email}, "GolangUzb70\n", h.cfg, "./api/helper/email/forgotpassword.html", otp)
+	err = email.SendEmail(ctxWithCancel, []string{res.Email}, "GolangUzb70\n", h.cfg, "./api/helper/email/forgotpassword.html", &models.Otp{
+		Email: res.Email,
+		Code:  code,
+	})
+	if err != nil {
+		h.revokeOtp(ctx, otp.PurposeForgotPassword, res.Email)
+	}
 	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserForgotPassword: email.SendEmail()", nil) {
 		return
 	}
+	metrics.OtpsSent.WithLabelValues(otp.PurposeForgotPassword).Inc()
 
 	h.HandleResponse(ctx, err, http.StatusOK, Success, "We have sent otp to your email  address.", nil)
 }
@@ -315,9 +307,8 @@ func (h *handlerV1) UserForgotPassword(ctx *gin.Context) {
 // @Failure default {object}  	models.StandardResponse
 func (h *handlerV1) UserForgotPasswordVerify(ctx *gin.Context) {
 	var (
-		body    models.UserForgotPasswordVerifyReq
-		req     models.UserGetReq
-		otpBody models.Otp
+		body models.UserForgotPasswordVerifyReq
+		req  models.UserGetReq
 	)
 
 	err := ctx.ShouldBindJSON(&body)
@@ -331,7 +322,7 @@ func (h *handlerV1) UserForgotPasswordVerify(ctx *gin.Context) {
 	} else {
 		req.UserName = body.UserNameOrEmail
 	}
-	ctxWithCancel, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	ctxWithCancel, cancel := context.WithTimeout(AuditContext(*h, ctx), time.Second*time.Duration(h.cfg.ContextTimeout))
 	defer cancel()
 
 	res, err := h.storage.Postgres().UserGet(ctxWithCancel, &req)
@@ -339,23 +330,8 @@ func (h *handlerV1) UserForgotPasswordVerify(ctx *gin.Context) {
 		return
 	}
 
-	otpAny, err := h.redis.Get(res.Email)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserForgotPasswordVerify: redis.Get(res.Email)", nil) {
-		return
-	}
-
-	if cast.ToString(otpAny) == "" {
-		h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "otp expired", nil)
-		return
-	}
-
-	err = json.Unmarshal([]byte(cast.ToString(otpAny)), &otpBody)
-	if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "UserForgotPasswordVerify: json.Unmarshal()", nil) {
-		return
-	}
-
-	if otpBody.Code != body.Otp {
-		h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "otp incorrect", nil)
+	err = h.otp.Verify(ctxWithCancel, otp.PurposeForgotPassword, res.Email, body.Otp)
+	if h.HandleOtpError(ctx, err, "UserForgotPasswordVerify: h.otp.Verify()") {
 		return
 	}
 
@@ -364,13 +340,14 @@ func (h *handlerV1) UserForgotPasswordVerify(ctx *gin.Context) {
 		return
 	}
 
-	err = h.storage.Postgres().UpdateSingleField(ctxWithCancel, &models.UpdateSingleFieldReq{
-		Id:       res.Id,
-		Table:    "users",
-		Column:   "hashed_password",
-		NewValue: res.Password,
+	actor := audit.ActorFrom(ctxWithCancel)
+	actor.Sub, actor.Role = res.Id, res.Role
+
+	err = h.storage.Postgres().UserPasswordUpdate(audit.WithActor(ctxWithCancel, actor), &models.UserPasswordUpdateReq{
+		Id:             res.Id,
+		HashedPassword: res.Password,
 	})
-	if h.HandleDatabaseLevelWithMessage(ctx, err, "UserForgotPasswordVerify:h.storage.Postgres().UpdateSingleField()") {
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "UserForgotPasswordVerify:h.storage.Postgres().UserPasswordUpdate()") {
 		return
 	}
 
@@ -384,7 +361,9 @@ func (h *handlerV1) UserForgotPasswordVerify(ctx *gin.Context) {
 // @Security    BearerAuth
 // @Accept      json
 // @Produce		json
+// @Param       If-None-Match header string false "ETag of the cached copy, 304 is returned when it is still current"
 // @Success		200 	{object}  models.UserResponse
+// @Success		304
 // @Failure     default {object}  models.StandardResponse
 func (h *handlerV1) UserGet(ctx *gin.Context) {
 	claim, err := GetClaims(*h, ctx)
@@ -392,13 +371,69 @@ func (h *handlerV1) UserGet(ctx *gin.Context) {
 		return
 	}
 
-	res, err := h.storage.Postgres().UserGet(context.Background(), &models.UserGetReq{
+	res, err := h.storage.Postgres().UserGet(ctx.Request.Context(), &models.UserGetReq{
 		Id: claim.Sub,
 	})
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "UserGet:h.storage.Postgres().UserGet()") {
 		return
 	}
 
+	etag := ETag(res.Version)
+	ctx.Header("ETag", etag)
+	if IfNoneMatch(ctx, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/user/list [GET]
+// @Summary		Get users list
+// @Tags        User
+// @Description	Here admins can list users. Pass cursor (empty for the first page) to paginate by next_cursor/prev_cursor instead of page.
+// @Description	The total count is calculated for page requests by default and for cursor requests only when with_count=true.
+// @Description	Filter with filter[field][op]=value and sort with sort=-created_at,user_name like in user exports.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       filters query models.UserFindReq true "filters"
+// @Success		200 	{object}  models.UserFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) UserFind(ctx *gin.Context) {
+	var (
+		dbReq = &models.UserFindReq{}
+		err   error
+	)
+
+	dbReq.Page, err = ParsePageQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid page param", nil) {
+		return
+	}
+
+	dbReq.Limit, err = ParseLimitQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid limit param", nil) {
+		return
+	}
+
+	dbReq.Cursor, dbReq.Keyset = ctx.GetQuery("cursor")
+	dbReq.WithCount, err = ParseWithCountQueryParam(ctx, !dbReq.Keyset)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid with_count param", nil) {
+		return
+	}
+
+	dbReq.Filter, err = ParseFilterQueryParams(ctx, models.UserFields)
+	if err != nil {
+		h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, err.Error(), nil)
+		return
+	}
+	dbReq.Sort = ctx.Query("sort")
+
+	res, err := h.storage.Postgres().UserFind(ctx.Request.Context(), dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "UserFind: h.storage.Postgres().UserFind()") {
+		return
+	}
+
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
 }
 
@@ -410,6 +445,7 @@ func (h *handlerV1) UserGet(ctx *gin.Context) {
 // @Accept      json
 // @Produce		json
 // @Param       post   body       models.UserApiUpdateReq true "post info"
+// @Param       If-Match header string false "ETag from UserGet, 412 is returned when the user was changed since"
 // @Success		200 	{object}  models.UserResponse
 // @Failure     default {object}  models.StandardResponse
 func (h *handlerV1) UserUpdate(ctx *gin.Context) {
@@ -427,14 +463,22 @@ func (h *handlerV1) UserUpdate(ctx *gin.Context) {
 		return
 	}
 
-	res, err := h.storage.Postgres().UserUpdate(context.Background(), &models.UserUpdateReq{
+	version, err := ParseIfMatchHeader(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid If-Match header", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().UserUpdate(AuditContext(*h, ctx), &models.UserUpdateReq{
 		Id:       claim.Sub,
 		UserName: body.UserName,
+		Version:  version,
 	})
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "h.storage.Postgres().UserUpdate()") {
 		return
 	}
 
+	ctx.Header("ETag", ETag(res.Version))
+
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
 }
 
@@ -453,10 +497,19 @@ func (h *handlerV1) UserDelete(ctx *gin.Context) {
 		return
 	}
 
-	err = h.storage.Postgres().UserDelete(context.Background(), &models.UserDeleteReq{Id: claim.Sub})
+	err = h.storage.Postgres().UserDelete(AuditContext(*h, ctx), &models.UserDeleteReq{Id: claim.Sub})
 	if h.HandleDatabaseLevelWithMessage(ctx, err, "UserDelete: h.storage.Postgres().UserDelete()") {
 		return
 	}
 
 	h.HandleResponse(ctx, nil, http.StatusOK, Success, "Successfully deleted", nil)
+}
+
+// revokeOtp drops a code which could not be sent, so the user can ask for
+// another one without waiting for the cooldown.
+func (h *handlerV1) revokeOtp(ctx *gin.Context, purpose, recipient string) {
+	// the request may have timed out while sending
+	if err := h.otp.Revoke(etc.WithoutCancel(ctx.Request.Context()), purpose, recipient); err != nil {
+		h.log.Ctx(ctx.Request.Context()).Error("revokeOtp: h.otp.Revoke()", err)
+	}
 }
\ No newline at end of file
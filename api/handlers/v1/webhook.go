@@ -0,0 +1,338 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/webhook"
+)
+
+// @Router		/webhook [POST]
+// @Summary		Create webhook
+// @Tags        Webhook
+// @Description	Here a webhook can be subscribed to template.created, template.updated and template.deleted events.
+// @Description	The secret signs the X-Webhook-Signature header (t=<unix time>,v1=<HMAC-SHA256 of "<unix time>.<body>">),
+// @Description	it is generated when empty and returned only here.
+// @Description	url should resolve to public addresses only, redirects of the endpoint are not followed.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       post   body       models.WebhookCreateReq true "webhook info"
+// @Success		200 	{object}  models.WebhookResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookCreate(ctx *gin.Context) {
+	body := &models.WebhookCreateReq{}
+	err := ctx.ShouldBindJSON(&body)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
+		return
+	}
+
+	err = validateWebhook(ctx.Request.Context(), body.Url, body.EventTypes)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, fmt.Sprint(err), nil) {
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+	body.OwnerSub = claim.Sub
+
+	if body.Secret == "" {
+		body.Secret, err = webhook.NewSecret()
+		if h.HandleResponse(ctx, err, http.StatusInternalServerError, InternalServerError, "WebhookCreate: webhook.NewSecret()", nil) {
+			return
+		}
+	}
+
+	res, err := h.storage.Postgres().WebhookCreate(ctx.Request.Context(), body)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookCreate: h.storage.Postgres().WebhookCreate()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/webhook/{id} [GET]
+// @Summary		Get webhook
+// @Tags        Webhook
+// @Description	Here a webhook of the user can be got.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Success		200 	{object}  models.WebhookResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookGet(ctx *gin.Context) {
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().WebhookGet(ctx.Request.Context(), &models.WebhookGetReq{
+		Id:       ctx.Param("id"),
+		OwnerSub: claim.Sub,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookGet: h.storage.Postgres().WebhookGet()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/webhook/list [GET]
+// @Summary		Get webhooks list
+// @Tags        Webhook
+// @Description	Here webhooks of the user can be got, newest first.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       filters query models.WebhookFindReq true "filters"
+// @Success		200 	{object}  models.WebhookFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookFind(ctx *gin.Context) {
+	var (
+		dbReq = &models.WebhookFindReq{}
+		err   error
+	)
+
+	dbReq.Page, err = ParsePageQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid page param", nil) {
+		return
+	}
+
+	dbReq.Limit, err = ParseLimitQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid limit param", nil) {
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+	dbReq.OwnerSub = claim.Sub
+
+	ctxWithCancel, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	defer cancel()
+
+	res, err := h.storage.Postgres().WebhookFind(ctxWithCancel, dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookFind: h.storage.Postgres().WebhookFind()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/webhook [PUT]
+// @Summary		Update webhook
+// @Tags        Webhook
+// @Description	Here a webhook can be changed or paused with active=false. An empty secret keeps the current one.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       post   body       models.WebhookUpdateReq true "webhook info"
+// @Success		200 	{object}  models.WebhookResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookUpdate(ctx *gin.Context) {
+	body := &models.WebhookUpdateReq{}
+	err := ctx.ShouldBindJSON(&body)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid body", nil) {
+		return
+	}
+
+	err = validateWebhook(ctx.Request.Context(), body.Url, body.EventTypes)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, fmt.Sprint(err), nil) {
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+	body.OwnerSub = claim.Sub
+
+	res, err := h.storage.Postgres().WebhookUpdate(ctx.Request.Context(), body)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookUpdate: h.storage.Postgres().WebhookUpdate()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/webhook/{id} [DELETE]
+// @Summary		Delete webhook
+// @Tags        Webhook
+// @Description	Here a webhook can be deleted together with its deliveries.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "id"
+// @Success		200 	{object}  models.StandardResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookDelete(ctx *gin.Context) {
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+
+	err = h.storage.Postgres().WebhookDelete(ctx.Request.Context(), &models.WebhookDeleteReq{
+		Id:       ctx.Param("id"),
+		OwnerSub: claim.Sub,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookDelete: h.storage.Postgres().WebhookDelete()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "Successfully deleted", nil)
+}
+
+// @Router		/webhook/{id}/deliveries [GET]
+// @Summary		Get webhook deliveries
+// @Tags        Webhook
+// @Description	Here deliveries of a webhook can be got, newest first. Dead deliveries failed all attempts and are sent again only by redelivery.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     string true "webhook id"
+// @Param       filters query models.WebhookDeliveryFindReq true "filters"
+// @Success		200 	{object}  models.WebhookDeliveryFindResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookDeliveryFind(ctx *gin.Context) {
+	var (
+		dbReq = &models.WebhookDeliveryFindReq{WebhookId: ctx.Param("id")}
+		err   error
+	)
+
+	dbReq.Page, err = ParsePageQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid page param", nil) {
+		return
+	}
+
+	dbReq.Limit, err = ParseLimitQueryParam(ctx)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid limit param", nil) {
+		return
+	}
+
+	dbReq.Status = ctx.Query("status")
+	switch dbReq.Status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		h.HandleResponse(ctx, fmt.Errorf(BadRequest), http.StatusBadRequest, BadRequest, "invalid status param", nil)
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+	dbReq.OwnerSub = claim.Sub
+
+	ctxWithCancel, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*time.Duration(h.cfg.ContextTimeout))
+	defer cancel()
+
+	res, err := h.storage.Postgres().WebhookDeliveryFind(ctxWithCancel, dbReq)
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookDeliveryFind: h.storage.Postgres().WebhookDeliveryFind()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/webhook/delivery/{id} [GET]
+// @Summary		Get webhook delivery
+// @Tags        Webhook
+// @Description	Here a delivery can be got with the log of its attempts and their response codes.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     int true "delivery id"
+// @Success		200 	{object}  models.WebhookDeliveryResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookDeliveryGet(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid id param", nil) {
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().WebhookDeliveryGet(ctx.Request.Context(), &models.WebhookDeliveryGetReq{
+		Id:       id,
+		OwnerSub: claim.Sub,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookDeliveryGet: h.storage.Postgres().WebhookDeliveryGet()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+// @Router		/webhook/delivery/{id}/redeliver [POST]
+// @Summary		Redeliver webhook delivery
+// @Tags        Webhook
+// @Description	Here a delivery can be sent again right away with a fresh number of attempts, also when it is dead or succeeded.
+// @Security    BearerAuth
+// @Accept      json
+// @Produce		json
+// @Param       id       path     int true "delivery id"
+// @Success		200 	{object}  models.WebhookDeliveryResponse
+// @Failure     default {object}  models.StandardResponse
+func (h *handlerV1) WebhookRedeliver(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if h.HandleResponse(ctx, err, http.StatusBadRequest, BadRequest, "invalid id param", nil) {
+		return
+	}
+
+	claim, err := GetClaims(*h, ctx)
+	if h.HandleResponse(ctx, err, http.StatusUnauthorized, UnAuthorized, "invalid authorization", nil) {
+		return
+	}
+
+	res, err := h.storage.Postgres().WebhookRedeliver(ctx.Request.Context(), &models.WebhookDeliveryGetReq{
+		Id:       id,
+		OwnerSub: claim.Sub,
+	})
+	if h.HandleDatabaseLevelWithMessage(ctx, err, "WebhookRedeliver: h.storage.Postgres().WebhookRedeliver()") {
+		return
+	}
+
+	h.HandleResponse(ctx, nil, http.StatusOK, Success, "", res)
+}
+
+func validateWebhook(ctx context.Context, rawUrl string, eventTypes []string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url should be an absolute http or https url")
+	}
+
+	// the worker checks the address again on every delivery, this only
+	// rejects urls that can never be delivered to
+	if _, err := webhook.ResolvePublic(ctx, u.Hostname()); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			return fmt.Errorf("url should not point to a private, loopback, link-local, reserved or multicast address")
+		}
+		return fmt.Errorf("url host can not be resolved")
+	}
+
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("at least one event type should be given")
+	}
+	for _, eventType := range eventTypes {
+		if !webhook.IsEventType(eventType) {
+			return fmt.Errorf("unknown event type %q, it should be one of %s", eventType, strings.Join(webhook.EventTypes, ", "))
+		}
+	}
+
+	return nil
+}
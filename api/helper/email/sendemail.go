@@ -2,15 +2,28 @@ package email
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"log"
 	"net/smtp"
 
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/tracing"
+	"github.com/spf13/cast"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func SendEmail(to []string, subject string, cfg config.Config, htmlpath string, body any) error {
+var tracer = tracing.Tracer("github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/helper/email")
+
+func SendEmail(ctx context.Context, to []string, subject string, cfg config.Config, htmlpath string, body any) (err error) {
+	_, span := tracer.Start(ctx, "smtp send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.NetPeerName(cfg.SMTPHost), semconv.NetPeerPort(cast.ToInt(cfg.SMTPPort))),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	t, err := template.ParseFiles(htmlpath)
 	if err != nil {
 		log.Println(err)
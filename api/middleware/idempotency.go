@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/handlers/v1"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLength  = 255
+	// responses larger than this are not kept, retries run the handler again
+	idempotencyMaxResponseBody = 1 << 20
+)
+
+// idempotentResponse is the first response to a request, replayed to its
+// retries.
+type idempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+type idempotency struct {
+	store   redisrepo.InMemoryStorageI
+	locker  *redisrepo.Locker
+	log     *logger.Logger
+	cfg     config.Config
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewIdempotency keeps the first response to requests sent with an
+// Idempotency-Key header and replays it to retries with the same key, so
+// retried requests do not e.g. create a template twice. Keys are scoped by
+// the user and route, and a key reused with another request body gets 422.
+// A retry arriving while the first request is still running gets 409.
+// 429 and 5xx responses are not kept, so they can be retried.
+func NewIdempotency(store redisrepo.InMemoryStorageI, log *logger.Logger, cfg config.Config) gin.HandlerFunc {
+	i := &idempotency{
+		store:   redisrepo.Namespaced(store, "idempotency"),
+		locker:  redisrepo.NewLocker(store),
+		log:     log,
+		cfg:     cfg,
+		ttl:     time.Duration(cfg.IdempotencyTTL) * time.Second,
+		lockTTL: time.Duration(cfg.IdempotencyLockTTL) * time.Second,
+	}
+
+	return i.handle
+}
+
+func (i *idempotency) handle(c *gin.Context) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" || c.FullPath() == "" {
+		c.Next()
+		return
+	}
+	if len(key) > idempotencyKeyMaxLength {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.StandardResponse{
+			Status:  v1.BadRequest,
+			Message: "Idempotency-Key header is too long",
+		})
+		return
+	}
+
+	// as large as the largest upload
+	maxBody := int64(i.cfg.ImportMaxSize)
+	if int64(i.cfg.MaxImageSize) > maxBody {
+		maxBody = int64(i.cfg.MaxImageSize)
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody<<20))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.StandardResponse{
+			Status:  v1.BadRequest,
+			Message: "invalid body",
+		})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	// the response is saved and the lock released even when the client
+	// has gone away in the meantime
+	ctx, cancel := context.WithTimeout(etc.WithoutCancel(c.Request.Context()), i.lockTTL)
+	defer cancel()
+
+	scope := hash(subject(c, i.cfg.SignInKey), c.Request.Method, c.FullPath(), key)
+	fingerprint := hash(c.Request.URL.RawQuery, string(body))
+
+	if i.replay(ctx, c, scope, fingerprint) {
+		return
+	}
+
+	lock, err := i.locker.Acquire(ctx, "idempotency:"+scope, i.lockTTL)
+	if err == redisrepo.ErrLocked {
+		c.AbortWithStatusJSON(http.StatusConflict, models.StandardResponse{
+			Status:  v1.Conflict,
+			Message: "A request with this Idempotency-Key is in progress",
+		})
+		return
+	}
+	if err != nil {
+		i.log.Ctx(ctx).Error("idempotency: acquiring lock", err)
+		c.Next()
+		return
+	}
+	defer func() {
+		if err := lock.Release(ctx); err != nil {
+			i.log.Ctx(ctx).Error("idempotency: releasing lock", err)
+		}
+	}()
+
+	// the first request may have finished after replay looked
+	if i.replay(ctx, c, scope, fingerprint) {
+		return
+	}
+
+	writer := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+
+	status := writer.Status()
+	if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError || writer.overflow {
+		return
+	}
+
+	err = i.store.SetJSON(ctx, scope, &idempotentResponse{
+		Fingerprint: fingerprint,
+		Status:      status,
+		Header:      writer.Header().Clone(),
+		Body:        writer.body.Bytes(),
+	}, i.ttl)
+	if err != nil {
+		i.log.Ctx(ctx).Error("idempotency: saving response", err)
+	}
+}
+
+// replay responds with the kept response of scope and reports whether
+// there was one.
+func (i *idempotency) replay(ctx context.Context, c *gin.Context, scope, fingerprint string) bool {
+	res := &idempotentResponse{}
+	err := i.store.GetJSON(ctx, scope, res)
+	if err == redisrepo.ErrNotFound {
+		return false
+	}
+	if err != nil {
+		// without Redis requests are served as if they had no key
+		i.log.Ctx(ctx).Error("idempotency: reading response", err)
+		return false
+	}
+
+	if res.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, models.StandardResponse{
+			Status:  v1.IdempotencyKeyReused,
+			Message: "Idempotency-Key was already used for another request",
+		})
+		return true
+	}
+
+	for name, values := range res.Header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(res.Status, res.Header.Get("Content-Type"), res.Body)
+	c.Abort()
+	return true
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the body written through it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *recordingWriter) record(b []byte) {
+	if w.overflow || w.body.Len()+len(b) > idempotencyMaxResponseBody {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
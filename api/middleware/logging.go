@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	token "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/tokens"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/requestid"
+	"github.com/spf13/cast"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewRequestID gives every request an id, the one in X-Request-ID when the
+// client sent a valid one and a new one otherwise. The id is put in the
+// context of the request and responded with.
+func NewRequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// NewLogger puts a logger adding the request id, method, route, user and
+// trace id to every line in the context of the request and logs each
+// request with its status and latency once it is served. The raw path and
+// query are not logged, they may hold tokens.
+func NewLogger(log *logger.Logger, cfg config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := c.Request.Context()
+
+		fields := []logger.Field{
+			logger.String("request_id", requestid.FromContext(ctx)),
+			logger.String("method", c.Request.Method),
+			logger.String("route", c.FullPath()),
+		}
+		if sub := subject(c, cfg.SignInKey); sub != "" {
+			fields = append(fields, logger.String("sub", sub))
+		}
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			fields = append(fields, logger.String("trace_id", span.TraceID().String()))
+		}
+		l := log.With(fields...)
+		c.Request = c.Request.WithContext(logger.WithContext(ctx, l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		args := []interface{}{
+			logger.Int("status", status),
+			logger.Duration("latency_ms", time.Since(start)),
+			logger.Int("size", size),
+			logger.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, logger.String("errors", c.Errors.String()))
+		}
+
+		switch {
+		case status >= 500:
+			l.Error("request", args...)
+		case status >= 400:
+			l.Warn("request", args...)
+		default:
+			l.Info("request", args...)
+		}
+	}
+}
+
+// subject returns the sub of the access token of the request, or "" when
+// it has no valid one.
+func subject(c *gin.Context, signInKey string) string {
+	jwtToken := c.GetHeader("Authorization")
+	if jwtToken == "" {
+		return ""
+	}
+	claims, err := token.ExtractClaim(jwtToken, []byte(signInKey))
+	if err != nil {
+		return ""
+	}
+	return cast.ToString(claims["sub"])
+}
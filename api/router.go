@@ -1,6 +1,8 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/casbin/casbin/v2"
 	defaultrolemanager "github.com/casbin/casbin/v2/rbac/default-role-manager"
 	"github.com/casbin/casbin/v2/util"
@@ -12,9 +14,11 @@ import (
 	t "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api/tokens"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/requestid"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
-	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -37,7 +41,7 @@ type Option struct {
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-func New(log *logger.Logger, cfg config.Config, strg storage.StorageI) *gin.Engine {
+func New(log *logger.Logger, cfg config.Config, strg storage.StorageI, inMemory redisrepo.InMemoryStorageI) *gin.Engine {
 	casbinEnforcer, err := casbin.NewEnforcer(cfg.AuthConfigPath, cfg.CSVFilePath)
 	if err != nil {
 		log.Error("casbin enforcer error", err)
@@ -52,13 +56,6 @@ func New(log *logger.Logger, cfg config.Config, strg storage.StorageI) *gin.Engi
 	casbinEnforcer.GetRoleManager().(*defaultrolemanager.RoleManager).AddMatchingFunc("keyMatch", util.KeyMatch)
 	casbinEnforcer.GetRoleManager().(*defaultrolemanager.RoleManager).AddMatchingFunc("keyMatch3", util.KeyMatch3)
 
-	pool := &redis.Pool{
-		MaxIdle: 10,
-		Dial: func() (redis.Conn, error) {
-			return redis.Dial("tcp", cfg.RedisHost+":"+cfg.RedisPort)
-		},
-	}
-
 	jwtHandler := t.JWTHandler{
 		SigninKey: cfg.SignInKey,
 		Log:       log,
@@ -66,7 +63,11 @@ func New(log *logger.Logger, cfg config.Config, strg storage.StorageI) *gin.Engi
 
 	router := gin.New()
 
-	router.Use(gin.Logger())
+	router.Use(otelgin.Middleware(cfg.TracingServiceName, otelgin.WithFilter(traced)))
+	router.Use(middleware.NewRequestID())
+	router.Use(middleware.NewLogger(log, cfg))
+	// before Recovery, so it sees the 500 of a panic
+	router.Use(middleware.NewMetrics())
 	router.Use(gin.Recovery())
 
 	h := v1.New(&v1.HandlerV1Config{
@@ -74,18 +75,30 @@ func New(log *logger.Logger, cfg config.Config, strg storage.StorageI) *gin.Engi
 		Cfg:        cfg,
 		Postgres:   strg,
 		JWTHandler: jwtHandler,
-		Redis:      redisrepo.NewRedisRepo(pool),
+		Redis:      inMemory,
+		Enforcer:   casbinEnforcer,
 	})
 
+	// probes are registered before the auth middleware, which applies to
+	// the routes registered after it only
+	router.GET("/healthz", h.Healthz)
+	router.GET("/readyz", h.Readyz)
+	router.GET("/version", h.Version)
+	if cfg.MetricsEnabled {
+		router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
 	corsConfig.AllowCredentials = true
 	corsConfig.AllowHeaders = []string{"*"}
 	corsConfig.AllowBrowserExtensions = true
 	corsConfig.AllowMethods = []string{"*"}
+	corsConfig.ExposeHeaders = []string{"ETag", middleware.IdempotentReplayedHeader, requestid.Header}
 	router.Use(cors.New(corsConfig))
 
 	router.Use(middleware.NewAuth(casbinEnforcer, jwtHandler, cfg))
+	router.Use(middleware.NewIdempotency(inMemory, log, cfg))
 
 	api := router.Group("/v1")
 
@@ -97,23 +110,65 @@ func New(log *logger.Logger, cfg config.Config, strg storage.StorageI) *gin.Engi
 	user.GET("/forgot-password/:user_name_or_email", h.UserForgotPassword)
 	user.POST("/forgot-password/verify", h.UserForgotPasswordVerify)
 	user.GET("/profile", h.UserGet)
+	user.GET("/list", h.UserFind)
 	user.PUT("", h.UserUpdate)
 	user.DELETE("", h.UserDelete)
+	user.GET("/export", h.UserExport)
+	user.POST("/import", h.UserImport)
 
 	template := api.Group("/template")
 	template.POST("", h.TemplateCreate)
+	template.POST("/bulk", h.TemplateBulk)
 	template.GET("/:id", h.TemplateGet)
 	template.GET("/list", h.TemplateFind)
+	template.GET("/export", h.TemplateExport)
+	template.POST("/import", h.TemplateImport)
 	template.PUT("", h.TemplateUpdate)
 	template.DELETE(":id", h.TemplateDelete)
+	template.GET("/:id/revisions", h.TemplateRevisionFind)
+	template.GET("/:id/revisions/diff", h.TemplateRevisionDiff)
+	template.GET("/:id/revisions/:revision", h.TemplateRevisionGet)
+	template.POST("/:id/revisions/:revision/restore", h.TemplateRestore)
+	template.GET("/:id/collaborators", h.TemplateGrantFind)
+	template.POST("/:id/collaborators", h.TemplateGrant)
+	template.DELETE("/:id/collaborators/:user_id", h.TemplateGrantDelete)
+	template.GET("/:id/share-links", h.TemplateShareLinkFind)
+	template.POST("/:id/share-links", h.TemplateShareLinkCreate)
+	template.DELETE("/:id/share-links/:link_id", h.TemplateShareLinkDelete)
+	template.GET("/shared/:token", h.TemplateSharedGet)
+
+	api.GET("/import/:id", h.ImportJobGet)
 
 	media := api.Group("/media")
 	api.Static("/media", "./media")
 	media.POST("/photo", h.UploadMedia)
 
-	// Don't delete this line, it is used to modify the file automatically
+	audit := api.Group("/audit")
+	audit.GET("/events", h.AuditEventFind)
+
+	api.GET("/cache/stats", h.CacheStats)
+	api.GET("/db/query-stats", h.QueryStats)
+
+	webhook := api.Group("/webhook")
+	webhook.POST("", h.WebhookCreate)
+	webhook.GET("/:id", h.WebhookGet)
+	webhook.GET("/list", h.WebhookFind)
+	webhook.PUT("", h.WebhookUpdate)
+	webhook.DELETE("/:id", h.WebhookDelete)
+	webhook.GET("/:id/deliveries", h.WebhookDeliveryFind)
+	webhook.GET("/delivery/:id", h.WebhookDeliveryGet)
+	webhook.POST("/delivery/:id/redeliver", h.WebhookRedeliver)
 
 	url := ginSwagger.URL("swagger/doc.json")
 	api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 	return router
 }
+
+// traced tells whether a request is traced, probes and scrapes are not.
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
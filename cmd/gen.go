@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/gen"
+)
+
+const genUsage = `usage: gen crud <entity> [field:type ...]
+
+Generates the migration pair, models, storage methods, handlers, routes and
+casbin policies of the entity. The entity and fields are snake_case, types
+are string, int, float and bool. Run it from the project root, e.g.
+
+  gen crud blog_post title:string views:int published:bool`
+
+// runGen implements the gen subcommand.
+func runGen(args []string) {
+	if len(args) < 2 || args[0] != "crud" {
+		fmt.Println(genUsage)
+		os.Exit(2)
+	}
+
+	entity, err := gen.ParseEntity(args[1], args[2:])
+	if err != nil {
+		fmt.Printf("%s\n\n%s\n", err, genUsage)
+		os.Exit(2)
+	}
+
+	files, err := gen.CRUD(".", entity)
+	if err != nil {
+		fmt.Println("gen crud failed:", err)
+		os.Exit(1)
+	}
+
+	for _, f := range files {
+		fmt.Println(f)
+	}
+}
@@ -1,29 +1,168 @@
 package main
 
 import (
-	"log"
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/api"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/db"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/metrics"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/tracing"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		runGen(os.Args[2:])
+		return
+	}
+
 	cfg := config.Load()
 	logger := logger.New(cfg.LogLevel)
 
-	db, err := db.New(cfg)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, logger, os.Args[2:])
+		return
+	}
+
+	if cfg.CursorSignKey == "" {
+		logger.Fatal("CURSOR_SIGN_KEY is required")
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
 	if err != nil {
-		logger.Error("Error while connecting to database", err)
-	} else {
-		logger.Info("Successfully connected to database")
+		logger.Fatal("Error while setting up tracing", err)
+	}
+
+	db, err := db.New(cfg, db.Logger(logger))
+	if err != nil {
+		logger.Fatal("Error while connecting to database", err)
+	}
+	logger.Info("Successfully connected to database")
+
+	if err := autoMigrate(cfg, logger); err != nil {
+		logger.Fatal("Database schema is not up to date", err)
+	}
+
+	pool := redisrepo.NewPool(cfg)
+	usesRedis := cfg.InMemoryStorage != "memory" || cfg.EventsSink != "memory"
+	if usesRedis {
+		if err := redisrepo.Ping(pool); err != nil {
+			logger.Fatal("Error while connecting to redis", err)
+		}
+	}
+	inMemory := redisrepo.New(cfg, pool)
+
+	strg := storage.New(db, logger, cfg, inMemory)
+
+	if cfg.MetricsEnabled {
+		metricsPool := pool
+		if !usesRedis {
+			metricsPool = nil
+		}
+		metrics.Register(db.Db.DB, metricsPool, strg.CacheStats)
 	}
 
-	router := api.New(logger, cfg, storage.New(db, logger, cfg))
+	// canceled by SIGINT or SIGTERM, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	workers := &sync.WaitGroup{}
+	if cfg.OutboxRelayEnabled {
+		runWorker(ctx, workers, newRelay(cfg, logger, strg, pool))
+	}
+	if cfg.WebhookWorkerEnabled {
+		runWorker(ctx, workers, newWebhookWorker(cfg, logger, strg))
+	}
+	if cfg.ImportWorkerEnabled {
+		runWorker(ctx, workers, newImportWorker(cfg, logger, strg))
+	}
 
-	if err := router.Run(":" + cfg.HTTPPort); err != nil {
-		log.Fatal("failed to run http server", err)
+	server := &http.Server{
+		Addr:              ":" + cfg.HTTPPort,
+		Handler:           api.New(logger, cfg, strg, inMemory),
+		ReadHeaderTimeout: time.Duration(cfg.HTTPReadHeaderTimeout) * time.Second,
+		ReadTimeout:       time.Duration(cfg.HTTPReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.HTTPWriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.HTTPIdleTimeout) * time.Second,
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Http server is listening on " + server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		logger.Error("Http server failed", err)
+		exitCode = 1
+	case <-ctx.Done():
+		logger.Info("Shutting down")
+	}
+	stop()
+
+	if !shutdown(logger, cfg, server, workers) {
+		exitCode = 1
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("Error while flushing spans", err)
+	}
+	cancel()
+
+	// nothing uses them anymore, the workers may still when they did not
+	// stop in time but the process is exiting anyway
+	if err := pool.Close(); err != nil {
+		logger.Error("Error while closing redis pool", err)
+	}
+	db.Close()
+
+	os.Exit(exitCode)
+}
+
+// runWorker runs w in the background until ctx is done.
+func runWorker(ctx context.Context, workers *sync.WaitGroup, w interface{ Run(context.Context) }) {
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		w.Run(ctx)
+	}()
+}
+
+// shutdown waits for the requests being served and the workers to finish
+// within cfg.ShutdownTimeout and reports whether they did.
+func shutdown(log *logger.Logger, cfg config.Config, server *http.Server, workers *sync.WaitGroup) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	ok := true
+	if err := server.Shutdown(ctx); err != nil {
+		log.Error("Http server did not stop in time", err)
+		ok = false
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Error("Background workers did not stop in time")
+		ok = false
+	}
+
+	return ok
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/migration"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up [n]           apply n pending migrations, all of them when n is omitted
+  down <n>         roll back n migrations
+  down --all       roll back all migrations
+  status           print the applied and the expected versions
+  force <version>  set the version without running migrations, clears the dirty flag
+  goto <version>   migrate up or down to the version`
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(cfg config.Config, log *logger.Logger, args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	// arguments are checked before connecting, so a typo fails fast
+	var run func(m *migration.Migrator) error
+	switch args[0] {
+	case "up":
+		n := optionalNumberArg(args)
+		run = func(m *migration.Migrator) error { return m.Up(n) }
+	case "down":
+		if len(args) > 1 && args[1] == "--all" {
+			run = (*migration.Migrator).DownAll
+			break
+		}
+		n := positiveNumberArg(args)
+		run = func(m *migration.Migrator) error { return m.Down(n) }
+	case "force":
+		version := requiredNumberArg(args)
+		run = func(m *migration.Migrator) error { return m.Force(version) }
+	case "goto":
+		version := uint(requiredNumberArg(args))
+		run = func(m *migration.Migrator) error { return m.Goto(version) }
+	case "status":
+		run = func(m *migration.Migrator) error {
+			st, err := m.Status()
+			if err == nil {
+				fmt.Printf("version: %d\ndirty:   %t\nlatest:  %d\npending: %v\n", st.Version, st.Dirty, st.Latest, st.Pending)
+			}
+			return err
+		}
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	m, err := migration.New(cfg)
+	if err != nil {
+		log.Fatal("migrate: failed to open database", err)
+	}
+	defer m.Close()
+
+	err = run(m)
+	if err != nil {
+		log.Fatal("migrate "+args[0]+" failed", err)
+	}
+	log.Info("migrate " + args[0] + " finished")
+}
+
+// autoMigrate applies pending migrations when enabled and refuses to start
+// when the schema is still behind the version this binary expects.
+func autoMigrate(cfg config.Config, log *logger.Logger) error {
+	m, err := migration.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if cfg.PostgresAutoMigrate {
+		if err := m.Up(0); err != nil {
+			return err
+		}
+		log.Info("Database migrations applied")
+	}
+
+	return m.Check()
+}
+
+func optionalNumberArg(args []string) int {
+	if len(args) < 2 {
+		return 0
+	}
+	return requiredNumberArg(args)
+}
+
+func positiveNumberArg(args []string) int {
+	n := requiredNumberArg(args)
+	if n == 0 {
+		fmt.Printf("invalid number %q\n\n%s\n", args[1], migrateUsage)
+		os.Exit(2)
+	}
+	return n
+}
+
+func requiredNumberArg(args []string) int {
+	if len(args) < 2 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 0 {
+		fmt.Printf("invalid number %q\n\n%s\n", args[1], migrateUsage)
+		os.Exit(2)
+	}
+	return n
+}
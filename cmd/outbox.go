@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/importer"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/outbox"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/webhook"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage"
+	"github.com/gomodule/redigo/redis"
+)
+
+// newRelay builds the outbox relay publishing to the sink chosen by
+// cfg.EventsSink and to webhook deliveries.
+func newRelay(cfg config.Config, log *logger.Logger, strg storage.StorageI, pool *redis.Pool) *outbox.Relay {
+	var sink events.Sink
+	switch cfg.EventsSink {
+	case "memory":
+		sink = &events.MemorySink{}
+	default:
+		sink = events.NewRedisStreamSink(pool, cfg.EventsStream, cfg.EventsStreamMaxLen)
+	}
+
+	return &outbox.Relay{
+		Storage:     strg.Postgres(),
+		Sink:        events.MultiSink{sink, &webhook.Sink{Storage: strg.Postgres()}},
+		Log:         log,
+		Interval:    time.Duration(cfg.OutboxPollInterval) * time.Millisecond,
+		BatchSize:   cfg.OutboxBatchSize,
+		MaxAttempts: cfg.OutboxMaxAttempts,
+		MaxBackoff:  time.Duration(cfg.OutboxMaxBackoff) * time.Second,
+	}
+}
+
+// newWebhookWorker builds the worker sending webhook deliveries.
+func newWebhookWorker(cfg config.Config, log *logger.Logger, strg storage.StorageI) *webhook.Worker {
+	return &webhook.Worker{
+		Storage:     strg.Postgres(),
+		Client:      webhook.NewClient(time.Duration(cfg.WebhookTimeout) * time.Second),
+		Log:         log,
+		Interval:    time.Duration(cfg.WebhookPollInterval) * time.Millisecond,
+		BatchSize:   cfg.WebhookBatchSize,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		MaxBackoff:  time.Duration(cfg.WebhookMaxBackoff) * time.Second,
+	}
+}
+
+// newImportWorker builds the worker running import jobs.
+func newImportWorker(cfg config.Config, log *logger.Logger, strg storage.StorageI) *importer.Worker {
+	return &importer.Worker{
+		Storage:   strg.Postgres(),
+		Log:       log,
+		Interval:  time.Duration(cfg.ImportPollInterval) * time.Millisecond,
+		ChunkSize: cfg.ImportChunkSize,
+		Lease:     time.Duration(cfg.ImportLease) * time.Second,
+	}
+}
@@ -10,30 +10,83 @@ import (
 
 // Config ...
 type Config struct {
-	OtpTimeout                int // seconds
-	ContextTimeout            int
-	Environment               string // develop, staging, production
-	LogLevel                  string // DEBUG, INFO ...
-	HTTPPort                  string
-	PostgresHost              string
-	PostgresPort              string
-	PostgresDatabase          string
-	PostgresUser              string
-	PostgresPassword          string
-	PostgresConnectionTimeOut int // seconds
-	PostgresConnectionTry     int
-	BaseUrl                   string
-	SMTPEmail                 string
-	SMTPEmailPass             string
-	SMTPHost                  string
-	SMTPPort                  string
-	SignInKey                 string
-	AuthConfigPath            string
-	CSVFilePath               string
-	RedisHost                 string
-	RedisPort                 string
-	AccessTokenTimout         int // MINUTES
-	MaxImageSize              int // Mb
+	OtpTimeout                 int // seconds
+	OtpMaxAttempts             int
+	OtpResendCooldown          int // seconds
+	OtpSecret                  string
+	ContextTimeout             int
+	Environment                string // develop, staging, production
+	LogLevel                   string // DEBUG, INFO ...
+	HTTPPort                   string
+	HTTPReadHeaderTimeout      int // seconds
+	HTTPReadTimeout            int // seconds
+	HTTPWriteTimeout           int // seconds
+	HTTPIdleTimeout            int // seconds
+	ShutdownTimeout            int // seconds
+	HealthCheckTimeout         int // milliseconds
+	MetricsEnabled             bool
+	TracingExporter            string // none, stdout, otlp
+	TracingOtlpEndpoint        string // host:port
+	TracingOtlpInsecure        bool
+	TracingSampleRatio         float64
+	TracingServiceName         string
+	PostgresHost               string
+	PostgresPort               string
+	PostgresDatabase           string
+	PostgresUser               string
+	PostgresPassword           string
+	PostgresConnectionTimeOut  int // seconds
+	PostgresConnectionTry      int
+	PostgresAutoMigrate        bool
+	PostgresSlowQueryThreshold int // milliseconds, 0 disables the log
+	BaseUrl                    string
+	SMTPEmail                  string
+	SMTPEmailPass              string
+	SMTPHost                   string
+	SMTPPort                   string
+	SignInKey                  string
+	CursorSignKey              string
+	AuthConfigPath             string
+	CSVFilePath                string
+	RedisHost                  string
+	RedisPort                  string
+	RedisPassword              string
+	RedisDB                    int
+	RedisMaxIdle               int
+	RedisMaxActive             int // 0 is unlimited
+	RedisIdleTimeout           int // seconds
+	RedisTestOnBorrow          int // seconds idle before a connection is pinged
+	RedisDialTimeout           int // seconds
+	RedisTLS                   bool
+	RedisTLSSkipVerify         bool
+	InMemoryStorage            string // redis, memory
+	IdempotencyTTL             int    // seconds
+	IdempotencyLockTTL         int    // seconds
+	CacheTemplateEnabled       bool
+	CacheTemplateTTL           int // seconds
+	CacheUserEnabled           bool
+	CacheUserTTL               int    // seconds
+	EventsSink                 string // redis, memory
+	EventsStream               string
+	EventsStreamMaxLen         int
+	OutboxRelayEnabled         bool
+	OutboxPollInterval         int // milliseconds
+	OutboxBatchSize            int
+	OutboxMaxAttempts          int
+	OutboxMaxBackoff           int // seconds
+	WebhookWorkerEnabled       bool
+	WebhookPollInterval        int // milliseconds
+	WebhookBatchSize           int
+	WebhookTimeout             int // seconds
+	WebhookMaxAttempts         int
+	WebhookMaxBackoff          int // seconds
+	ImportWorkerEnabled        bool
+	ImportPollInterval         int // milliseconds
+	ImportChunkSize            int
+	ImportLease                int // seconds
+	ImportMaxSize              int // Mb
+	AccessTokenTimout          int // MINUTES
+	MaxImageSize               int // Mb
 }
 
 // Load loads environment vars and inflates Config
@@ -49,6 +102,20 @@ func Load() Config {
 	c.Environment = cast.ToString(getOrReturnDefault("ENVIRONMENT", "develop"))
 	c.LogLevel = cast.ToString(getOrReturnDefault("LOG_LEVEL", "DEBUG"))
 	c.HTTPPort = cast.ToString(getOrReturnDefault("HTTP_PORT", "8000"))
+	c.HTTPReadHeaderTimeout = cast.ToInt(getOrReturnDefault("HTTP_READ_HEADER_TIMEOUT", 10))
+	c.HTTPReadTimeout = cast.ToInt(getOrReturnDefault("HTTP_READ_TIMEOUT", 60))
+	c.HTTPWriteTimeout = cast.ToInt(getOrReturnDefault("HTTP_WRITE_TIMEOUT", 60))
+	c.HTTPIdleTimeout = cast.ToInt(getOrReturnDefault("HTTP_IDLE_TIMEOUT", 120))
+	c.ShutdownTimeout = cast.ToInt(getOrReturnDefault("SHUTDOWN_TIMEOUT", 30))
+	c.HealthCheckTimeout = cast.ToInt(getOrReturnDefault("HEALTH_CHECK_TIMEOUT", 1000))
+	c.MetricsEnabled = cast.ToBool(getOrReturnDefault("METRICS_ENABLED", true))
+
+	// Tracing
+	c.TracingExporter = cast.ToString(getOrReturnDefault("TRACING_EXPORTER", "none"))
+	c.TracingOtlpEndpoint = cast.ToString(getOrReturnDefault("TRACING_OTLP_ENDPOINT", "localhost:4318"))
+	c.TracingOtlpInsecure = cast.ToBool(getOrReturnDefault("TRACING_OTLP_INSECURE", true))
+	c.TracingSampleRatio = cast.ToFloat64(getOrReturnDefault("TRACING_SAMPLE_RATIO", 1))
+	c.TracingServiceName = cast.ToString(getOrReturnDefault("TRACING_SERVICE_NAME", "monolithic-template"))
 	c.BaseUrl = cast.ToString(getOrReturnDefault("BASE_URL", "http://localhost:8000/v1/"))
 
 	// Postgres
@@ -59,8 +126,11 @@ func Load() Config {
 	c.PostgresPassword = cast.ToString(getOrReturnDefault("POSTGRES_PASSWORD", "usersecret"))
 	c.PostgresConnectionTimeOut = cast.ToInt(getOrReturnDefault("POSTGRES_CONNECTION_TIMEOUT", 5))
 	c.PostgresConnectionTry = cast.ToInt(getOrReturnDefault("POSTGRES_CONNECTION_TRY", 10))
+	c.PostgresAutoMigrate = cast.ToBool(getOrReturnDefault("POSTGRES_AUTO_MIGRATE", false))
+	c.PostgresSlowQueryThreshold = cast.ToInt(getOrReturnDefault("POSTGRES_SLOW_QUERY_THRESHOLD", 200))
 
 	c.SignInKey = cast.ToString(getOrReturnDefault("SIGN_IN_KEY", "ASJDKLFJASasdFASE2SD2dafa"))
+	c.CursorSignKey = cast.ToString(getOrReturnDefault("CURSOR_SIGN_KEY", ""))
 	c.AuthConfigPath = cast.ToString(getOrReturnDefault("AUTH_CONFIG_PATH", "./config/auth.conf"))
 	c.CSVFilePath = cast.ToString(getOrReturnDefault("CSV_FILE_PATH", "./config/auth.csv"))
 	// Email sending
@@ -72,7 +142,56 @@ func Load() Config {
 	// in mermory storage
 	c.RedisHost = cast.ToString(getOrReturnDefault("REDIS_HOST", "localhost"))
 	c.RedisPort = cast.ToString(getOrReturnDefault("REDIS_PORT", "6379"))
+	c.RedisPassword = cast.ToString(getOrReturnDefault("REDIS_PASSWORD", ""))
+	c.RedisDB = cast.ToInt(getOrReturnDefault("REDIS_DB", 0))
+	c.RedisMaxIdle = cast.ToInt(getOrReturnDefault("REDIS_MAX_IDLE", 10))
+	c.RedisMaxActive = cast.ToInt(getOrReturnDefault("REDIS_MAX_ACTIVE", 100))
+	c.RedisIdleTimeout = cast.ToInt(getOrReturnDefault("REDIS_IDLE_TIMEOUT", 300))
+	c.RedisTestOnBorrow = cast.ToInt(getOrReturnDefault("REDIS_TEST_ON_BORROW", 60))
+	c.RedisDialTimeout = cast.ToInt(getOrReturnDefault("REDIS_DIAL_TIMEOUT", 5))
+	c.RedisTLS = cast.ToBool(getOrReturnDefault("REDIS_TLS", false))
+	c.RedisTLSSkipVerify = cast.ToBool(getOrReturnDefault("REDIS_TLS_SKIP_VERIFY", false))
+	c.InMemoryStorage = cast.ToString(getOrReturnDefault("IN_MEMORY_STORAGE", "redis"))
 	c.OtpTimeout = cast.ToInt(getOrReturnDefault("OTP_TIMEOUT", 300))
+	c.OtpMaxAttempts = cast.ToInt(getOrReturnDefault("OTP_MAX_ATTEMPTS", 5))
+	c.OtpResendCooldown = cast.ToInt(getOrReturnDefault("OTP_RESEND_COOLDOWN", 60))
+	c.OtpSecret = cast.ToString(getOrReturnDefault("OTP_SECRET", c.SignInKey))
+
+	// Idempotency keys
+	c.IdempotencyTTL = cast.ToInt(getOrReturnDefault("IDEMPOTENCY_TTL", 86400))
+	c.IdempotencyLockTTL = cast.ToInt(getOrReturnDefault("IDEMPOTENCY_LOCK_TTL", 60))
+
+	// Read cache
+	c.CacheTemplateEnabled = cast.ToBool(getOrReturnDefault("CACHE_TEMPLATE_ENABLED", true))
+	c.CacheTemplateTTL = cast.ToInt(getOrReturnDefault("CACHE_TEMPLATE_TTL", 300))
+	c.CacheUserEnabled = cast.ToBool(getOrReturnDefault("CACHE_USER_ENABLED", true))
+	c.CacheUserTTL = cast.ToInt(getOrReturnDefault("CACHE_USER_TTL", 60))
+
+	// Domain events
+	c.EventsSink = cast.ToString(getOrReturnDefault("EVENTS_SINK", "redis"))
+	c.EventsStream = cast.ToString(getOrReturnDefault("EVENTS_STREAM", "events"))
+	c.EventsStreamMaxLen = cast.ToInt(getOrReturnDefault("EVENTS_STREAM_MAX_LEN", 100000))
+	c.OutboxRelayEnabled = cast.ToBool(getOrReturnDefault("OUTBOX_RELAY_ENABLED", true))
+	c.OutboxPollInterval = cast.ToInt(getOrReturnDefault("OUTBOX_POLL_INTERVAL", 1000))
+	c.OutboxBatchSize = cast.ToInt(getOrReturnDefault("OUTBOX_BATCH_SIZE", 100))
+	c.OutboxMaxAttempts = cast.ToInt(getOrReturnDefault("OUTBOX_MAX_ATTEMPTS", 20))
+	c.OutboxMaxBackoff = cast.ToInt(getOrReturnDefault("OUTBOX_MAX_BACKOFF", 300))
+
+	// Webhooks
+	c.WebhookWorkerEnabled = cast.ToBool(getOrReturnDefault("WEBHOOK_WORKER_ENABLED", true))
+	c.WebhookPollInterval = cast.ToInt(getOrReturnDefault("WEBHOOK_POLL_INTERVAL", 1000))
+	c.WebhookBatchSize = cast.ToInt(getOrReturnDefault("WEBHOOK_BATCH_SIZE", 20))
+	c.WebhookTimeout = cast.ToInt(getOrReturnDefault("WEBHOOK_TIMEOUT", 10))
+	c.WebhookMaxAttempts = cast.ToInt(getOrReturnDefault("WEBHOOK_MAX_ATTEMPTS", 10))
+	c.WebhookMaxBackoff = cast.ToInt(getOrReturnDefault("WEBHOOK_MAX_BACKOFF", 3600))
+
+	// Import
+	c.ImportWorkerEnabled = cast.ToBool(getOrReturnDefault("IMPORT_WORKER_ENABLED", true))
+	c.ImportPollInterval = cast.ToInt(getOrReturnDefault("IMPORT_POLL_INTERVAL", 1000))
+	c.ImportChunkSize = cast.ToInt(getOrReturnDefault("IMPORT_CHUNK_SIZE", 500))
+	c.ImportLease = cast.ToInt(getOrReturnDefault("IMPORT_LEASE", 120))
+	c.ImportMaxSize = cast.ToInt(getOrReturnDefault("IMPORT_MAX_SIZE", 10))
+
 	c.ContextTimeout = cast.ToInt(getOrReturnDefault("CONTEXT_TIMOUT", 7))
 	c.AccessTokenTimout = cast.ToInt(getOrReturnDefault("ACCESS_TOKEN_TIMEOUT", 300))
 
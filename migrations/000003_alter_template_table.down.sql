@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS templates_template_name_trgm_idx;
+DROP INDEX IF EXISTS templates_search_vector_idx;
+ALTER TABLE templates DROP COLUMN IF EXISTS search_vector;
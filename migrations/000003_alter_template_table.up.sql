@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+   GENERATED ALWAYS AS (to_tsvector('english', coalesce(template_name, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS templates_search_vector_idx ON templates USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS templates_template_name_trgm_idx ON templates USING GIN (template_name gin_trgm_ops);
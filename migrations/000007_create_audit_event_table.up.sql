@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+   id BIGSERIAL NOT NULL PRIMARY KEY,
+   actor_sub VARCHAR(64) NOT NULL DEFAULT '',
+   actor_role VARCHAR(32) NOT NULL DEFAULT '',
+   ip VARCHAR(64) NOT NULL DEFAULT '',
+   user_agent TEXT NOT NULL DEFAULT '',
+   request_id VARCHAR(64) NOT NULL DEFAULT '',
+   action VARCHAR(64) NOT NULL,
+   resource_type VARCHAR(64) NOT NULL DEFAULT '',
+   resource_id VARCHAR(64) NOT NULL DEFAULT '',
+   diff JSONB NOT NULL DEFAULT '{}',
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_sub, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_resource_idx ON audit_events (resource_type, resource_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+
+-- audit events are append-only
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+   BEFORE UPDATE OR DELETE ON audit_events
+   FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
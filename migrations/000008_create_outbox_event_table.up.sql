@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+   id BIGSERIAL NOT NULL PRIMARY KEY,
+   aggregate_type VARCHAR(64) NOT NULL,
+   aggregate_id VARCHAR(64) NOT NULL,
+   event_type VARCHAR(64) NOT NULL,
+   payload JSONB NOT NULL DEFAULT '{}',
+   attempts INTEGER NOT NULL DEFAULT 0,
+   last_error TEXT NOT NULL DEFAULT '',
+   next_attempt_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   published_at TIMESTAMP WITHOUT TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events (aggregate_type, aggregate_id, id)
+   WHERE published_at IS NULL;
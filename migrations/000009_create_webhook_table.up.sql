@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+   id UUID NOT NULL PRIMARY KEY,
+   owner_sub VARCHAR(64) NOT NULL,
+   url TEXT NOT NULL,
+   secret VARCHAR(128) NOT NULL,
+   event_types TEXT[] NOT NULL DEFAULT '{}',
+   active BOOLEAN NOT NULL DEFAULT TRUE,
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_owner_sub_idx ON webhooks (owner_sub, created_at);
+CREATE INDEX IF NOT EXISTS webhooks_event_types_idx ON webhooks USING GIN (event_types);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+   id BIGSERIAL NOT NULL PRIMARY KEY,
+   webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+   event_id BIGINT NOT NULL,
+   event_type VARCHAR(64) NOT NULL,
+   payload JSONB NOT NULL,
+   status VARCHAR(16) NOT NULL DEFAULT 'pending',
+   attempts INT NOT NULL DEFAULT 0,
+   next_attempt_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   last_response_code INT NOT NULL DEFAULT 0,
+   last_error TEXT NOT NULL DEFAULT '',
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+   id BIGSERIAL NOT NULL PRIMARY KEY,
+   delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+   response_code INT NOT NULL DEFAULT 0,
+   error TEXT NOT NULL DEFAULT '',
+   duration_ms BIGINT NOT NULL DEFAULT 0,
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_delivery_idx ON webhook_delivery_attempts (delivery_id, id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS template_revisions;
+DROP FUNCTION IF EXISTS template_revisions_immutable();
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS template_revisions (
+   id BIGSERIAL NOT NULL PRIMARY KEY,
+   template_id UUID NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+   revision INT NOT NULL,
+   actor_sub VARCHAR(64) NOT NULL DEFAULT '',
+   snapshot JSONB NOT NULL,
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   UNIQUE (template_id, revision)
+);
+
+-- revisions are immutable, they go away only with their template
+CREATE OR REPLACE FUNCTION template_revisions_immutable() RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'template_revisions is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER template_revisions_immutable
+   BEFORE UPDATE ON template_revisions
+   FOR EACH ROW EXECUTE FUNCTION template_revisions_immutable();
+
+-- the current state of existing templates is their first known revision
+INSERT INTO template_revisions (template_id, revision, snapshot, created_at)
+SELECT id, version, jsonb_build_object(
+   'id', id,
+   'template_name', template_name,
+   'created_at', to_char(created_at, 'Dy, DD Mon YYYY HH24:MI:SS "UTC"'),
+   'updated_at', to_char(updated_at, 'Dy, DD Mon YYYY HH24:MI:SS "UTC"'),
+   'version', version
+), updated_at
+FROM templates
+ON CONFLICT (template_id, revision) DO NOTHING;
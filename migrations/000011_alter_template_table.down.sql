@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS template_tags;
+DROP TABLE IF EXISTS tags;
+
+ALTER TABLE templates DROP COLUMN IF EXISTS status;
+ALTER TABLE templates DROP COLUMN IF EXISTS body_schema;
+ALTER TABLE templates DROP COLUMN IF EXISTS body;
+ALTER TABLE templates DROP COLUMN IF EXISTS description;
@@ -0,0 +1,20 @@
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS body JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS body_schema JSONB;
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'draft'
+   CHECK (status IN ('draft', 'published', 'archived'));
+
+CREATE INDEX IF NOT EXISTS templates_status_idx ON templates (status, created_at);
+
+CREATE TABLE IF NOT EXISTS tags (
+   id BIGSERIAL NOT NULL PRIMARY KEY,
+   name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS template_tags (
+   template_id UUID NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+   tag_id BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+   PRIMARY KEY (template_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS template_tags_tag_id_idx ON template_tags (tag_id, template_id);
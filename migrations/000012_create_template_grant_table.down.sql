@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS template_share_links;
+DROP TABLE IF EXISTS template_grants;
+
+ALTER TABLE templates DROP COLUMN IF EXISTS owner_sub;
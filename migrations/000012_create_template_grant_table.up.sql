@@ -0,0 +1,27 @@
+-- templates created before owners existed have no owner and stay open to all users
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS owner_sub VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS templates_owner_sub_idx ON templates (owner_sub);
+
+CREATE TABLE IF NOT EXISTS template_grants (
+   template_id UUID NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+   user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+   role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor')),
+   granted_by VARCHAR(64) NOT NULL DEFAULT '',
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   PRIMARY KEY (template_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS template_grants_user_id_idx ON template_grants (user_id);
+
+CREATE TABLE IF NOT EXISTS template_share_links (
+   id UUID NOT NULL PRIMARY KEY,
+   template_id UUID NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+   token_hash VARCHAR(64) NOT NULL UNIQUE,
+   created_by VARCHAR(64) NOT NULL DEFAULT '',
+   expires_at TIMESTAMP WITHOUT TIME ZONE,
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS template_share_links_template_id_idx ON template_share_links (template_id, created_at);
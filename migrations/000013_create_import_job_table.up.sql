@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+   id UUID NOT NULL PRIMARY KEY,
+   kind VARCHAR(16) NOT NULL CHECK (kind IN ('template', 'user')),
+   format VARCHAR(16) NOT NULL CHECK (format IN ('csv', 'ndjson')),
+   status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+   owner_sub VARCHAR(64) NOT NULL,
+   owner_role VARCHAR(64) NOT NULL,
+   payload BYTEA,
+   total_rows INT NOT NULL DEFAULT 0,
+   processed_rows INT NOT NULL DEFAULT 0,
+   succeeded_rows INT NOT NULL DEFAULT 0,
+   failed_rows INT NOT NULL DEFAULT 0,
+   errors JSONB NOT NULL DEFAULT '[]',
+   message TEXT NOT NULL DEFAULT '',
+   locked_until TIMESTAMP WITHOUT TIME ZONE,
+   created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+   finished_at TIMESTAMP WITHOUT TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_unfinished_idx ON import_jobs (created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS import_jobs_owner_sub_idx ON import_jobs (owner_sub, created_at);
@@ -0,0 +1,2 @@
+-- a new token is given on every claim, only its holder can save progress
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS claim_token UUID;
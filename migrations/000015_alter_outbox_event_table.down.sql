@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS outbox_events_unpublished_idx;
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events (aggregate_type, aggregate_id, id)
+   WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS dead_at;
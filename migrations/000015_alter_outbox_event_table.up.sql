@@ -0,0 +1,6 @@
+-- events failing OUTBOX_MAX_ATTEMPTS times are dead, they no longer hold back later events of their aggregate
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP WITHOUT TIME ZONE;
+
+DROP INDEX IF EXISTS outbox_events_unpublished_idx;
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events (aggregate_type, aggregate_id, id)
+   WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,7 @@
+// Package migrations embeds the sql migrations so the binary can apply them itself.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
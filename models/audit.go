@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+)
+
+type AuditEventCreateReq struct {
+	Action       string                  `json:"action"`
+	ResourceType string                  `json:"resource_type"`
+	ResourceId   string                  `json:"resource_id"`
+	Diff         map[string]audit.Change `json:"diff"`
+}
+
+type AuditEventFindReq struct {
+	Page         int       `json:"page"`
+	Limit        int       `json:"limit"`
+	ActorSub     string    `json:"actor_sub"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type"`
+	ResourceId   string    `json:"resource_id"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+}
+
+type AuditEventFindResponse struct {
+	Events []*AuditEventResponse `json:"events"`
+	Count  int                   `json:"count"`
+}
+
+type AuditEventResponse struct {
+	Id           int64                   `json:"id"`
+	ActorSub     string                  `json:"actor_sub"`
+	ActorRole    string                  `json:"actor_role"`
+	Ip           string                  `json:"ip"`
+	UserAgent    string                  `json:"user_agent"`
+	RequestId    string                  `json:"request_id"`
+	Action       string                  `json:"action"`
+	ResourceType string                  `json:"resource_type"`
+	ResourceId   string                  `json:"resource_id"`
+	Diff         map[string]audit.Change `json:"diff"`
+	CreatedAt    string                  `json:"created_at"`
+}
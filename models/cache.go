@@ -0,0 +1,15 @@
+package models
+
+type CacheStats struct {
+	Entity     string  `json:"entity"`
+	Enabled    bool    `json:"enabled"`
+	TTLSeconds int     `json:"ttl_seconds"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	Errors     int64   `json:"errors"`
+	HitRatio   float64 `json:"hit_ratio"`
+}
+
+type CacheStatsResponse struct {
+	Entities []*CacheStats `json:"entities"`
+}
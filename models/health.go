@@ -0,0 +1,19 @@
+package models
+
+const (
+	HealthStatusOk          = "ok"
+	HealthStatusFail        = "fail"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthCheck is the result of one readiness check, why it failed is only
+// logged.
+type HealthCheck struct {
+	Status    string  `json:"status" enums:"ok,fail"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type ReadinessResponse struct {
+	Status string                  `json:"status" enums:"ok,unavailable"`
+	Checks map[string]*HealthCheck `json:"checks"`
+}
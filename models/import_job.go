@@ -0,0 +1,112 @@
+package models
+
+import "time"
+
+// What can be imported.
+const (
+	ImportKindTemplate = "template"
+	ImportKindUser     = "user"
+)
+
+// Import and export formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson" // one JSON object per line
+)
+
+// Import job statuses.
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusSucceeded = "succeeded" // every row was processed, some of them may have failed
+	ImportStatusFailed    = "failed"    // the file could not be processed
+)
+
+// ImportMaxErrors is how many row errors are kept for a job.
+const ImportMaxErrors = 1000
+
+type ImportJobCreateReq struct {
+	Kind      string
+	Format    string
+	OwnerSub  string
+	OwnerRole string
+	Payload   []byte
+}
+
+type ImportJobGetReq struct {
+	Id       string `json:"id"`
+	OwnerSub string `json:"-"`
+}
+
+// ImportRowError tells what is wrong with a row of the imported file.
+type ImportRowError struct {
+	Line    int    `json:"line"` // line of the file, the CSV header is line 1
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportJobResponse struct {
+	Id            string            `json:"id"`
+	Kind          string            `json:"kind" enums:"template,user"`
+	Format        string            `json:"format" enums:"csv,ndjson"`
+	Status        string            `json:"status" enums:"pending,running,succeeded,failed"`
+	TotalRows     int               `json:"total_rows"`
+	ProcessedRows int               `json:"processed_rows"`
+	SucceededRows int               `json:"succeeded_rows"`
+	FailedRows    int               `json:"failed_rows"`
+	Errors        []*ImportRowError `json:"errors"` // the first ImportMaxErrors of them
+	Message       string            `json:"message,omitempty"`
+	CreatedAt     string            `json:"created_at"`
+	UpdatedAt     string            `json:"updated_at"`
+	FinishedAt    string            `json:"finished_at,omitempty"`
+}
+
+type ImportJobClaimReq struct {
+	Lease time.Duration // the claimed job is not claimed again until the lease ends
+}
+
+// ImportJob is a claimed job with everything needed to run it.
+type ImportJob struct {
+	Id            string
+	Kind          string
+	Format        string
+	OwnerSub      string
+	OwnerRole     string
+	Payload       []byte
+	ProcessedRows int    // rows processed before the job was claimed again
+	ClaimToken    string // proves the job is still claimed when progress is saved
+}
+
+// ImportJobProgressReq saves how far a claimed job has got, extending
+// its lease.
+type ImportJobProgressReq struct {
+	Id            string
+	ClaimToken    string
+	Status        string
+	TotalRows     int
+	ProcessedRows int
+	SucceededRows int // added to the saved count
+	FailedRows    int // added to the saved count
+	Errors        []*ImportRowError
+	Message       string
+	Lease         time.Duration
+}
+
+// ImportJobChunkReq is a chunk of rows of a claimed job to create.
+type ImportJobChunkReq struct {
+	Templates *TemplateBulkReq // rows of template jobs
+	Users     []*UserCreateReq // rows of user jobs
+}
+
+// ImportJobChunkResult tells which rows of a chunk were created.
+type ImportJobChunkResult struct {
+	Templates []*TemplateBulkResult
+	Users     []error // of each of the users, nil for the created ones
+}
+
+// UserImportRow is a user to import. Imported users have no password and
+// set it with forgot password.
+type UserImportRow struct {
+	UserName string `json:"user_name"`
+	Email    string `json:"email"`
+}
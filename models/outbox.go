@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type OutboxEvent struct {
+	Id            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type OutboxPublishReq struct {
+	Limit       int           `json:"limit"`
+	MaxAttempts int           `json:"max_attempts"` // events failing this many times are dead
+	MaxBackoff  time.Duration `json:"max_backoff"`  // failed events are retried after 2^attempts seconds, up to MaxBackoff
+}
@@ -0,0 +1,17 @@
+package models
+
+type QueryStats struct {
+	Name    string  `json:"name"`
+	Count   int64   `json:"count"`
+	Errors  int64   `json:"errors"`
+	TotalMs float64 `json:"total_ms"`
+	MeanMs  float64 `json:"mean_ms"`
+	P50Ms   float64 `json:"p50_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+type QueryStatsResponse struct {
+	SlowQueryThresholdMs int           `json:"slow_query_threshold_ms"`
+	Queries              []*QueryStats `json:"queries"`
+}
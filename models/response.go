@@ -5,3 +5,10 @@ type StandardResponse struct {
 	Message string `json:"message"`
 	Data    any    `json:"data"`
 }
+
+// FieldError tells which field of a request is invalid, it is returned in
+// StandardResponse.Data of 400 responses.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
@@ -1,12 +1,121 @@
 package models
 
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/filter"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/schema"
+)
+
+// Template search modes.
+const (
+	SearchModePrefix   = "prefix"   // template_name starts with the search
+	SearchModeFullText = "fulltext" // full-text search with typo tolerant fallback
+)
+
+// Template statuses.
+const (
+	TemplateStatusDraft     = "draft"
+	TemplateStatusPublished = "published"
+	TemplateStatusArchived  = "archived"
+)
+
+// TemplateFields are the fields templates can be filtered and sorted by.
+var TemplateFields = filter.Fields{
+	"id":            {Type: filter.String, Filterable: true},
+	"template_name": {Type: filter.String, Filterable: true, Sortable: true},
+	"status":        {Type: filter.String, Filterable: true, Sortable: true},
+	"created_at":    {Type: filter.Time, Filterable: true, Sortable: true},
+	"updated_at":    {Type: filter.Time, Filterable: true, Sortable: true},
+}
+
+// TemplateContent is what a template is made of, it is replaced as a
+// whole by updates.
+type TemplateContent struct {
+	TemplateName string          `json:"template_name"`
+	Description  string          `json:"description"`
+	Body         json.RawMessage `json:"body" swaggertype:"object"`        // validated against body_schema when it is given
+	BodySchema   json.RawMessage `json:"body_schema" swaggertype:"object"` // optional JSON Schema of body
+	Tags         []string        `json:"tags"`
+	Status       string          `json:"status" enums:"draft,published,archived"` // draft when empty
+}
+
+// Validate normalizes the content and returns what is wrong with it.
+func (c *TemplateContent) Validate() []FieldError {
+	var fieldErrors []FieldError
+
+	if utf8.RuneCountInString(c.TemplateName) > 64 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "template_name", Message: "should be at most 64 characters"})
+	}
+
+	switch c.Status {
+	case "":
+		c.Status = TemplateStatusDraft
+	case TemplateStatusDraft, TemplateStatusPublished, TemplateStatusArchived:
+	default:
+		fieldErrors = append(fieldErrors, FieldError{Field: "status", Message: "should be one of draft, published, archived"})
+	}
+
+	c.Tags = NormalizeTags(c.Tags)
+	for i, tag := range c.Tags {
+		if utf8.RuneCountInString(tag) > 64 {
+			fieldErrors = append(fieldErrors, FieldError{Field: "tags." + strconv.Itoa(i), Message: "should be at most 64 characters"})
+		}
+	}
+
+	if len(c.Body) > 0 && string(c.Body) != "null" {
+		var body map[string]any
+		if json.Unmarshal(c.Body, &body) != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: "body", Message: "should be a JSON object"})
+			return fieldErrors
+		}
+	}
+
+	if len(c.BodySchema) > 0 && string(c.BodySchema) != "null" {
+		body := c.Body
+		if len(body) == 0 || string(body) == "null" {
+			body = json.RawMessage("{}")
+		}
+
+		violations, err := schema.Validate(c.BodySchema, body, "body")
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: "body_schema", Message: err.Error()})
+		}
+		for _, v := range violations {
+			fieldErrors = append(fieldErrors, FieldError{Field: v.Field, Message: v.Message})
+		}
+	}
+
+	return fieldErrors
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and repeated ones.
+func NormalizeTags(tags []string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
 type TemplateCreateReq struct {
-	TemplateName string `json:"template_name"`
+	TemplateContent
+	OwnerSub string `json:"-"`
 }
 
 type TemplateUpdateReq struct {
-	Id           string    `json:"id"`
-	TemplateName string `json:"template_name"`
+	Id string `json:"id"`
+	TemplateContent
+	Version int `json:"-"` // expected version from If-Match, 0 updates any version
 }
 
 type TemplateGetReq struct {
@@ -14,10 +123,18 @@ type TemplateGetReq struct {
 }
 
 type TemplateFindReq struct {
-	Page             int    `json:"page"`
-	Limit            int    `json:"limit"`
-	OrderByCreatedAt uint64 `json:"order_by_created_at"`
-	Search           string `json:"search"`
+	Page             int           `json:"page"`
+	Limit            int           `json:"limit"`
+	OrderByCreatedAt int           `json:"order_by_created_at"`
+	Search           string        `json:"search"`
+	SearchMode       string        `json:"search_mode" enums:"prefix,fulltext"`
+	Sort             string        `json:"sort"`
+	Cursor           string        `json:"cursor"`
+	Tags             []string      `json:"tag"` // templates having all of the tags
+	VisibleTo        *string       `json:"-"`   // only templates this user can read, all when nil
+	WithCount        bool          `json:"with_count"`
+	Keyset           bool          `json:"-"`
+	Filter           *filter.Query `json:"-"`
 }
 
 type TemplateDeleteReq struct {
@@ -25,13 +142,124 @@ type TemplateDeleteReq struct {
 }
 
 type TemplateFindResponse struct {
-	Templates []*TemplateResponse `json:"templates"`
-	Count     int                 `json:"count"`
+	Templates  []*TemplateResponse `json:"templates"`
+	Count      *int                `json:"count,omitempty"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	PrevCursor string              `json:"prev_cursor,omitempty"`
 }
 
 type TemplateResponse struct {
-	Id           string    `json:"id"`
-	TemplateName string `json:"template_name"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-}
\ No newline at end of file
+	Id           string          `json:"id"`
+	TemplateName string          `json:"template_name"`
+	Description  string          `json:"description"`
+	Body         json.RawMessage `json:"body,omitempty" swaggertype:"object"`        // not returned by TemplateFind
+	BodySchema   json.RawMessage `json:"body_schema,omitempty" swaggertype:"object"` // not returned by TemplateFind
+	Tags         []string        `json:"tags"`
+	Status       string          `json:"status"`
+	OwnerSub     string          `json:"owner_sub"`
+	CreatedAt    string          `json:"created_at"`
+	UpdatedAt    string          `json:"updated_at"`
+	Version      int             `json:"version"`
+	Rank         float64         `json:"rank,omitempty"`
+	Snippet      string          `json:"snippet,omitempty"`
+}
+
+type TemplateRevisionFindReq struct {
+	TemplateId string `json:"-"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+}
+
+type TemplateRevisionGetReq struct {
+	TemplateId string `json:"template_id"`
+	Revision   int    `json:"revision"`
+}
+
+type TemplateRevisionDiffReq struct {
+	TemplateId string `json:"-"`
+	From       int    `json:"from"`
+	To         int    `json:"to"`
+}
+
+type TemplateRestoreReq struct {
+	TemplateId string `json:"template_id"`
+	Revision   int    `json:"revision"`
+	Version    int    `json:"-"` // expected version from If-Match, 0 restores over any version
+}
+
+type TemplateRevisionFindResponse struct {
+	Revisions []*TemplateRevisionResponse `json:"revisions"`
+	Count     int                         `json:"count"`
+}
+
+// TemplateRevisionResponse is the template as it was saved at a version.
+type TemplateRevisionResponse struct {
+	TemplateId string            `json:"template_id"`
+	Revision   int               `json:"revision"` // the template version it was saved as
+	ActorSub   string            `json:"actor_sub"`
+	Snapshot   *TemplateResponse `json:"snapshot"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+type TemplateRevisionDiffResponse struct {
+	TemplateId string                  `json:"template_id"`
+	From       int                     `json:"from"`
+	To         int                     `json:"to"`
+	Diff       map[string]audit.Change `json:"diff"`
+}
+
+// Template bulk modes.
+const (
+	BulkModeAtomic     = "atomic"      // all of the operations are applied or none
+	BulkModeBestEffort = "best_effort" // every operation is applied on its own
+)
+
+// Template bulk operations.
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+// Template bulk operation statuses.
+const (
+	BulkStatusSucceeded = "succeeded"
+	BulkStatusFailed    = "failed"
+	BulkStatusAborted   = "aborted" // not applied because another operation failed in atomic mode
+)
+
+type TemplateBulkReq struct {
+	Mode       string                   `json:"mode" enums:"atomic,best_effort"` // atomic when empty
+	Operations []*TemplateBulkOperation `json:"operations"`
+	OwnerSub   string                   `json:"-"` // owner of the created templates
+}
+
+// TemplateBulkOperation creates, updates or deletes a template. Content is
+// given for create and update only.
+type TemplateBulkOperation struct {
+	Op      string `json:"op" enums:"create,update,delete"`
+	Id      string `json:"id"`      // template to update or delete
+	Version int    `json:"version"` // expected version for update and delete, 0 for any version
+	TemplateContent
+	Index int `json:"-"` // position in the request
+}
+
+type TemplateBulkResult struct {
+	Index      int               `json:"index"`
+	Op         string            `json:"op"`
+	Id         string            `json:"id,omitempty"`
+	Status     string            `json:"status" enums:"succeeded,failed,aborted"`
+	HttpStatus int               `json:"http_status,omitempty"` // status the single item endpoint would respond with
+	Code       string            `json:"code,omitempty"`        // like status of StandardResponse
+	Message    string            `json:"message,omitempty"`
+	Errors     []FieldError      `json:"errors,omitempty"`
+	Template   *TemplateResponse `json:"template,omitempty"`
+	Err        error             `json:"-"`
+}
+
+type TemplateBulkResponse struct {
+	Mode      string                `json:"mode"`
+	Results   []*TemplateBulkResult `json:"results"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// Template access roles. The owner can do everything, editors can read and
+// update, viewers can only read.
+const (
+	TemplateRoleOwner  = "owner"
+	TemplateRoleEditor = "editor"
+	TemplateRoleViewer = "viewer"
+)
+
+type TemplateAccessReq struct {
+	TemplateId string
+	UserId     string // empty for unauthorized requests
+}
+
+type TemplateAccessResponse struct {
+	OwnerSub string // empty for templates created before owners existed
+	Role     string // role of the user, empty when the user has none
+}
+
+type TemplateGrantReq struct {
+	TemplateId string `json:"-"`
+	UserId     string `json:"user_id"`
+	Role       string `json:"role" enums:"viewer,editor"`
+}
+
+type TemplateGrantFindReq struct {
+	TemplateId string `json:"-"`
+}
+
+type TemplateGrantDeleteReq struct {
+	TemplateId string `json:"-"`
+	UserId     string `json:"user_id"`
+}
+
+type TemplateCollaboratorFindResponse struct {
+	Collaborators []*TemplateCollaboratorResponse `json:"collaborators"`
+	Count         int                             `json:"count"`
+}
+
+type TemplateCollaboratorResponse struct {
+	UserId    string `json:"user_id"`
+	UserName  string `json:"user_name"`
+	Role      string `json:"role"`
+	GrantedBy string `json:"granted_by"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type TemplateShareLinkCreateReq struct {
+	TemplateId string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"` // never expires when empty
+	TokenHash  string     `json:"-"`
+}
+
+type TemplateShareLinkFindReq struct {
+	TemplateId string `json:"-"`
+}
+
+type TemplateShareLinkDeleteReq struct {
+	TemplateId string `json:"-"`
+	Id         string `json:"id"`
+}
+
+type TemplateSharedGetReq struct {
+	TokenHash string
+}
+
+type TemplateShareLinkFindResponse struct {
+	Links []*TemplateShareLinkResponse `json:"links"`
+	Count int                          `json:"count"`
+}
+
+type TemplateShareLinkResponse struct {
+	Id        string `json:"id"`
+	Url       string `json:"url,omitempty"` // only returned when the link is created
+	CreatedBy string `json:"created_by"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
@@ -1,5 +1,16 @@
 package models
 
+import "github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/filter"
+
+// UserFields are the fields users can be filtered and sorted by.
+var UserFields = filter.Fields{
+	"id":         {Type: filter.String, Filterable: true},
+	"user_name":  {Type: filter.String, Filterable: true, Sortable: true},
+	"email":      {Type: filter.String, Filterable: true, Sortable: true},
+	"created_at": {Type: filter.Time, Filterable: true, Sortable: true},
+	"updated_at": {Type: filter.Time, Filterable: true, Sortable: true},
+}
+
 type UserCheckRes struct {
 	Status string `json:"status"`
 }
@@ -33,6 +44,7 @@ type UserLoginRequest struct {
 type UserUpdateReq struct {
 	Id       string `json:"id"`
 	UserName string `json:"user_name"`
+	Version  int    `json:"-"` // expected version from If-Match, 0 updates any version
 }
 
 type UserApiUpdateReq struct {
@@ -46,8 +58,13 @@ type UserGetReq struct {
 }
 
 type UserFindReq struct {
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
+	Page      int           `json:"page"`
+	Limit     int           `json:"limit"`
+	Sort      string        `json:"sort"`
+	Cursor    string        `json:"cursor"`
+	WithCount bool          `json:"with_count"`
+	Keyset    bool          `json:"-"`
+	Filter    *filter.Query `json:"-"`
 }
 
 type UserDeleteReq struct {
@@ -55,8 +72,10 @@ type UserDeleteReq struct {
 }
 
 type UserFindResponse struct {
-	Users []*UserResponse `json:"users"`
-	Count int             `json:"count"`
+	Users      []*UserResponse `json:"users"`
+	Count      *int            `json:"count,omitempty"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"`
 }
 
 type UserResponse struct {
@@ -68,6 +87,13 @@ type UserResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	CreatedAt    string `json:"created_at"`
 	UpdatedAt    string `json:"updated_at"`
+	Version      int    `json:"version"`
+	Role         string `json:"role"`
+}
+
+type UserPasswordUpdateReq struct {
+	Id             string `json:"id"`
+	HashedPassword string `json:"hashed_password"`
 }
 
 type UserForgotPasswordVerifyReq struct {
@@ -75,3 +101,14 @@ type UserForgotPasswordVerifyReq struct {
 	Otp             string `json:"otp"`
 	UserNameOrEmail string `json:"user_name_or_email"`
 }
+
+// UserExportRow is a user as it is exported.
+type UserExportRow struct {
+	Id        string `json:"id"`
+	UserName  string `json:"user_name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	Version   int    `json:"version"`
+}
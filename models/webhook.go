@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook delivery statuses.
+const (
+	WebhookDeliveryPending   = "pending"   // waiting for the first attempt or a retry
+	WebhookDeliverySucceeded = "succeeded" // the endpoint answered 2xx
+	WebhookDeliveryDead      = "dead"      // all attempts failed, only a redelivery retries it
+)
+
+type WebhookCreateReq struct {
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"` // generated when empty
+	EventTypes []string `json:"event_types"`
+	OwnerSub   string   `json:"-"`
+}
+
+type WebhookUpdateReq struct {
+	Id         string   `json:"id"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"` // kept when empty
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	OwnerSub   string   `json:"-"`
+}
+
+type WebhookGetReq struct {
+	Id       string `json:"id"`
+	OwnerSub string `json:"-"`
+}
+
+type WebhookFindReq struct {
+	Page     int    `json:"page"`
+	Limit    int    `json:"limit"`
+	OwnerSub string `json:"-"`
+}
+
+type WebhookDeleteReq struct {
+	Id       string `json:"id"`
+	OwnerSub string `json:"-"`
+}
+
+type WebhookFindResponse struct {
+	Webhooks []*WebhookResponse `json:"webhooks"`
+	Count    int                `json:"count"`
+}
+
+type WebhookResponse struct {
+	Id         string   `json:"id"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"` // only returned when it is set
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+type WebhookDeliveryFindReq struct {
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+	WebhookId string `json:"-"`
+	Status    string `json:"status" enums:"pending,succeeded,dead"`
+	OwnerSub  string `json:"-"`
+}
+
+type WebhookDeliveryGetReq struct {
+	Id       int64  `json:"id"`
+	OwnerSub string `json:"-"`
+}
+
+type WebhookDeliveryFindResponse struct {
+	Deliveries []*WebhookDeliveryResponse `json:"deliveries"`
+	Count      int                        `json:"count"`
+}
+
+type WebhookDeliveryResponse struct {
+	Id               int64                     `json:"id"`
+	WebhookId        string                    `json:"webhook_id"`
+	EventId          int64                     `json:"event_id"`
+	EventType        string                    `json:"event_type"`
+	Payload          json.RawMessage           `json:"payload" swaggertype:"object"`
+	Status           string                    `json:"status"`
+	Attempts         int                       `json:"attempts"`
+	NextAttemptAt    string                    `json:"next_attempt_at"`
+	LastResponseCode int                       `json:"last_response_code"`
+	LastError        string                    `json:"last_error"`
+	CreatedAt        string                    `json:"created_at"`
+	UpdatedAt        string                    `json:"updated_at"`
+	Log              []*WebhookDeliveryAttempt `json:"log,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ResponseCode int    `json:"response_code"` // 0 when no response was received
+	Error        string `json:"error"`
+	DurationMs   int64  `json:"duration_ms"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// WebhookEnqueueReq creates a delivery of the event for every active
+// webhook subscribed to its type.
+type WebhookEnqueueReq struct {
+	EventId    int64
+	EventType  string
+	Payload    json.RawMessage // request body sent to the endpoints
+	TemplateId string          // template the event is about
+	OwnerSub   string          // owner of the template, "" for templates open to all users
+}
+
+type WebhookDeliveryClaimReq struct {
+	Limit int
+	Lease time.Duration // claimed deliveries are not claimed again until the lease ends
+}
+
+// WebhookDelivery is a claimed delivery with everything needed to send it.
+type WebhookDelivery struct {
+	Id        int64
+	WebhookId string
+	Url       string
+	Secret    string
+	EventType string
+	Payload   json.RawMessage
+	Attempts  int
+}
+
+type WebhookDeliveryRecordReq struct {
+	Id           int64
+	ResponseCode int
+	Error        string
+	Duration     time.Duration
+	Succeeded    bool
+	MaxAttempts  int           // the delivery is dead after so many failed attempts
+	MaxBackoff   time.Duration // failed deliveries are retried after 2^attempts seconds, up to MaxBackoff
+}
@@ -0,0 +1,116 @@
+// Package audit carries who is doing a request down to the storage layer
+// and computes the changes recorded in audit events.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// Actions recorded in audit events.
+const (
+	ActionUserRegister      = "user.register"
+	ActionUserLogin         = "user.login"
+	ActionUserLoginFailed   = "user.login_failed"
+	ActionUserPasswordReset = "user.password_reset"
+	ActionUserUpdate        = "user.update"
+	ActionUserDelete        = "user.delete"
+	ActionTemplateCreate    = "template.create"
+	ActionTemplateUpdate    = "template.update"
+	ActionTemplateDelete    = "template.delete"
+	ActionTemplateRestore   = "template.restore"
+	ActionTemplateGrant     = "template.grant"
+	ActionTemplateRevoke    = "template.revoke"
+	ActionTemplateShare     = "template.share"
+	ActionTemplateUnshare   = "template.unshare"
+)
+
+// Actor is who performs a request.
+type Actor struct {
+	Sub       string
+	Role      string
+	IP        string
+	UserAgent string
+	RequestId string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor carried by ctx, the zero Actor when there is none.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// Change is the value of a field before and after an action.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Redacted replaces values of secret fields in changes.
+const Redacted = "[redacted]"
+
+var (
+	// fields that change on every update and tell nothing
+	ignoredFields = map[string]bool{"updated_at": true, "access_token": true}
+	secretFields  = map[string]bool{"password": true, "refresh_token": true}
+)
+
+// Diff returns the fields which differ between before and after, both are
+// structs (or nil) marshalled to json objects. Secret fields are redacted.
+func Diff(before, after any) map[string]Change {
+	b, a := toMap(before), toMap(after)
+	diff := map[string]Change{}
+
+	for field := range union(b, a) {
+		if ignoredFields[field] || reflect.DeepEqual(b[field], a[field]) {
+			continue
+		}
+
+		change := Change{Before: b[field], After: a[field]}
+		if secretFields[field] {
+			change = Change{Before: redact(b, field), After: redact(a, field)}
+		}
+		diff[field] = change
+	}
+
+	return diff
+}
+
+func toMap(v any) map[string]any {
+	res := map[string]any{}
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return res
+	}
+
+	body, err := json.Marshal(v)
+	if err == nil {
+		_ = json.Unmarshal(body, &res)
+	}
+	return res
+}
+
+func union(a, b map[string]any) map[string]struct{} {
+	res := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		res[k] = struct{}{}
+	}
+	for k := range b {
+		res[k] = struct{}{}
+	}
+	return res
+}
+
+func redact(m map[string]any, field string) any {
+	if _, ok := m[field]; ok {
+		return Redacted
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package db
 
-import "time"
+import (
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+)
 
 // Option -.
 type Option func(*Postgres)
@@ -18,3 +22,10 @@ func ConnTimeout(timeout time.Duration) Option {
 		c.connTimeout = timeout
 	}
 }
+
+// Logger sets the logger slow queries are logged with.
+func Logger(log *logger.Logger) Option {
+	return func(c *Postgres) {
+		c.log = log
+	}
+}
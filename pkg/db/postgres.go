@@ -2,14 +2,21 @@
 package db
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/XSAM/otelsql"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/qustavo/sqlhooks/v2"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 const (
@@ -21,6 +28,8 @@ const (
 type Postgres struct {
 	connAttempts int
 	connTimeout  time.Duration
+	log          *logger.Logger
+	stats        *queryStats
 
 	Builder squirrel.StatementBuilderType
 	Db      *sqlx.DB
@@ -38,18 +47,13 @@ func New(cfg config.Config, opts ...Option) (*Postgres, error) {
 		opt(pg)
 	}
 
-	pgxUrl := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.PostgresUser,
-		cfg.PostgresPassword,
-		cfg.PostgresHost,
-		cfg.PostgresPort,
-		cfg.PostgresDatabase,
-	)
+	pgxUrl := ConnString(cfg)
+	pg.stats = newQueryStats(pg.log, time.Duration(cfg.PostgresSlowQueryThreshold)*time.Millisecond)
 
 	pg.Builder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	var err error
 	for pg.connAttempts > 0 {
-		pg.Db, err = sqlx.Connect("postgres", pgxUrl)
+		pg.Db, err = connect(pgxUrl, pg.stats)
 		if err == nil {
 			break
 		}
@@ -68,6 +72,59 @@ func New(cfg config.Config, opts ...Option) (*Postgres, error) {
 	return pg, nil
 }
 
+// connect opens a connection pool whose statements are traced, each one a
+// span with its SQL, and measured by hooks, and checks the database can be
+// reached.
+func connect(url string, hooks sqlhooks.Hooks) (*sqlx.DB, error) {
+	db := otelsql.OpenDB(connector{url: url, driver: sqlhooks.Wrap(&pq.Driver{}, hooks)},
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnectorConnect: true,
+			OmitRows:             true,
+		}),
+	)
+
+	dbx := sqlx.NewDb(db, "postgres")
+	if err := dbx.Ping(); err != nil {
+		dbx.Close()
+		return nil, err
+	}
+	return dbx, nil
+}
+
+// connector opens connections with driver, without registering it
+// globally.
+type connector struct {
+	url    string
+	driver driver.Driver
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.url)
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// QueryStats returns the stats of every statement run since the start.
+func (p *Postgres) QueryStats() []*models.QueryStats {
+	return p.stats.Stats()
+}
+
+// ConnString builds the postgres connection url from cfg.
+func ConnString(cfg config.Config) string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresHost,
+		cfg.PostgresPort,
+		cfg.PostgresDatabase,
+	)
+}
+
 // Close -.
 func (p *Postgres) Close() {
 	if p.Db != nil {
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+)
+
+// samples is how many of the latest durations of a query its percentiles
+// are computed over.
+const samples = 1024
+
+// modulePath prefixes the functions of this program, e.g. the methods of
+// the storage.
+var modulePath = strings.TrimSuffix(reflect.TypeOf(Postgres{}).PkgPath(), "pkg/db")
+
+// queryStats is the sqlhooks.Hooks measuring every statement. Statements
+// are named after the function of this program running them, e.g.
+// postgres.UserGet, and those taking threshold or longer are logged.
+type queryStats struct {
+	log       *logger.Logger
+	threshold time.Duration
+
+	mu      sync.Mutex
+	queries map[string]*queryStat
+}
+
+type queryStat struct {
+	count     int64
+	errors    int64
+	total     time.Duration
+	max       time.Duration
+	durations []time.Duration // ring of the latest samples
+	next      int
+}
+
+type startKey struct{}
+
+func newQueryStats(log *logger.Logger, threshold time.Duration) *queryStats {
+	return &queryStats{
+		log:       log,
+		threshold: threshold,
+		queries:   make(map[string]*queryStat),
+	}
+}
+
+func (s *queryStats) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (s *queryStats) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	s.record(ctx, nil, query, args)
+	return ctx, nil
+}
+
+func (s *queryStats) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	// ErrSkip makes database/sql try another way, it is not a failure
+	if err != driver.ErrSkip {
+		s.record(ctx, err, query, args)
+	}
+	return err
+}
+
+func (s *queryStats) record(ctx context.Context, err error, query string, args []interface{}) {
+	start, ok := ctx.Value(startKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+	name := queryName()
+
+	s.mu.Lock()
+	stat, ok := s.queries[name]
+	if !ok {
+		stat = &queryStat{}
+		s.queries[name] = stat
+	}
+	stat.add(elapsed, err)
+	s.mu.Unlock()
+
+	if s.log != nil && s.threshold > 0 && elapsed >= s.threshold {
+		s.log.Ctx(ctx).Warn("slow query",
+			logger.String("query", name),
+			logger.Duration("duration_ms", elapsed),
+			logger.String("sql", strings.Join(strings.Fields(query), " ")),
+			logger.Any("sql_args", redact(args)),
+			err,
+		)
+	}
+}
+
+func (q *queryStat) add(elapsed time.Duration, err error) {
+	q.count++
+	if err != nil {
+		q.errors++
+	}
+	q.total += elapsed
+	if elapsed > q.max {
+		q.max = elapsed
+	}
+
+	if len(q.durations) < samples {
+		q.durations = append(q.durations, elapsed)
+		return
+	}
+	q.durations[q.next] = elapsed
+	q.next = (q.next + 1) % samples
+}
+
+func (q *queryStat) stats(name string) *models.QueryStats {
+	sorted := make([]time.Duration, len(q.durations))
+	copy(sorted, q.durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &models.QueryStats{
+		Name:    name,
+		Count:   q.count,
+		Errors:  q.errors,
+		TotalMs: milliseconds(q.total),
+		MeanMs:  milliseconds(q.total / time.Duration(q.count)),
+		P50Ms:   milliseconds(percentile(sorted, 0.5)),
+		P99Ms:   milliseconds(percentile(sorted, 0.99)),
+		MaxMs:   milliseconds(q.max),
+	}
+}
+
+// Stats returns the stats of every query since the start, the ones taking
+// the most time in total first.
+func (s *queryStats) Stats() []*models.QueryStats {
+	s.mu.Lock()
+	res := make([]*models.QueryStats, 0, len(s.queries))
+	for name, stat := range s.queries {
+		res = append(res, stat.stats(name))
+	}
+	s.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].TotalMs > res[j].TotalMs })
+	return res
+}
+
+// percentile returns the nearest rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// queryName names a statement after the first function of this program
+// outside this package on the stack, e.g. postgres.UserGet for a statement
+// of (*postgresRepo).UserGet or a closure in it.
+func queryName() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, modulePath) && !strings.HasPrefix(frame.Function, modulePath+"pkg/db.") {
+			return shortName(frame.Function)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// shortName turns a.b/c/postgres.(*postgresRepo).UserGet.func1 into
+// postgres.UserGet.
+func shortName(function string) string {
+	function = function[strings.LastIndex(function, "/")+1:]
+
+	var parts []string
+	for _, part := range strings.Split(function, ".") {
+		if strings.HasPrefix(part, "(") {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
+
+// redact replaces args by their type, and the length of strings and bytes,
+// so slow query logs do not leak emails, password hashes or tokens.
+func redact(args []interface{}) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case nil:
+			res[i] = "NULL"
+		case string:
+			res[i] = fmt.Sprintf("string(%d)", len(arg))
+		case []byte:
+			res[i] = fmt.Sprintf("bytes(%d)", len(arg))
+		default:
+			res[i] = fmt.Sprintf("%T", arg)
+		}
+	}
+	return res
+}
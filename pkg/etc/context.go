@@ -0,0 +1,24 @@
+package etc
+
+import (
+	"context"
+	"time"
+)
+
+// WithoutCancel returns a context with the values of ctx which is never
+// canceled, e.g. to finish work started before ctx was canceled.
+func WithoutCancel(ctx context.Context) context.Context {
+	return withoutCancel{ctx}
+}
+
+type withoutCancel struct {
+	ctx context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (withoutCancel) Done() <-chan struct{} { return nil }
+
+func (withoutCancel) Err() error { return nil }
+
+func (c withoutCancel) Value(key any) any { return c.ctx.Value(key) }
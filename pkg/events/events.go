@@ -0,0 +1,81 @@
+// Package events defines domain events and the sinks they are published to.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Event types.
+const (
+	UserRegistered    = "user.registered"
+	UserUpdated       = "user.updated"
+	UserPasswordReset = "user.password_reset"
+	UserDeleted       = "user.deleted"
+	TemplateCreated   = "template.created"
+	TemplateUpdated   = "template.updated"
+	TemplateDeleted   = "template.deleted"
+)
+
+// Event is something which happened to an aggregate (a user, a template...).
+// Events of the same aggregate are published in the order they happened.
+type Event struct {
+	Id            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// Sink publishes events to other systems. Delivery is at least once, so
+// consumers should deduplicate by Event.Id.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// fields which never leave the service
+var secretFields = []string{"password", "refresh_token", "access_token"}
+
+// Payload marshals the state of an aggregate into an event payload,
+// dropping secret fields.
+func Payload(v any) (json.RawMessage, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return json.RawMessage("{}"), nil
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]any{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		// not an object, nothing to drop
+		return body, nil
+	}
+	for _, field := range secretFields {
+		delete(m, field)
+	}
+
+	return json.Marshal(m)
+}
+
+// MultiSink publishes every event to all of its sinks. Publish fails when
+// any sink fails, so the sinks see an event again on retry.
+type MultiSink []Sink
+
+// Publish -.
+func (m MultiSink) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
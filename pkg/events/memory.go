@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySink keeps published events in memory. It is meant for tests and
+// local runs without Redis.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []Event
+
+	// Err, when set, is returned by Publish instead of keeping the event.
+	Err error
+}
+
+// NewMemorySink -.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Publish -.
+func (s *MemorySink) Publish(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Err != nil {
+		return s.Err
+	}
+	s.events = append(s.events, event)
+
+	return nil
+}
+
+// Events returns a copy of the published events in the order they were published.
+func (s *MemorySink) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Event(nil), s.events...)
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RedisStreamSink appends events to a Redis stream, trimmed to about maxLen entries.
+type RedisStreamSink struct {
+	pool   *redis.Pool
+	stream string
+	maxLen int
+}
+
+// NewRedisStreamSink -.
+func NewRedisStreamSink(pool *redis.Pool, stream string, maxLen int) *RedisStreamSink {
+	return &RedisStreamSink{
+		pool:   pool,
+		stream: stream,
+		maxLen: maxLen,
+	}
+}
+
+// Publish -.
+func (s *RedisStreamSink) Publish(ctx context.Context, event Event) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	args := redis.Args{s.stream}
+	if s.maxLen > 0 {
+		args = args.Add("MAXLEN", "~", s.maxLen)
+	}
+	args = args.Add("*",
+		"id", event.Id,
+		"type", event.Type,
+		"aggregate_type", event.AggregateType,
+		"aggregate_id", event.AggregateId,
+		"payload", []byte(event.Payload),
+		"occurred_at", event.OccurredAt.Format(time.RFC3339Nano),
+	)
+
+	_, err = redis.DoContext(conn, ctx, "XADD", args...)
+	return err
+}
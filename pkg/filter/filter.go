@@ -0,0 +1,284 @@
+// Package filter parses the list query language shared by list endpoints:
+//
+//	filter[created_at][gte]=2023-01-02&filter[template_name][like]=foo&sort=-updated_at,template_name
+//
+// and translates it into squirrel conditions. Only fields whitelisted in
+// the resource's Fields are accepted.
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Type is the type a filter value is parsed into.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	Time
+)
+
+// Operators supported in filter[field][op].
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpIn     = "in"
+	OpLike   = "like"   // case insensitive "contains"
+	OpPrefix = "prefix" // case insensitive "starts with"
+	OpNull   = "null"   // true or false
+)
+
+// Field describes a field of a resource which can be used in filters or sort.
+type Field struct {
+	Column     string // defaults to the field name
+	Type       Type
+	Filterable bool
+	Sortable   bool
+}
+
+// Fields is the per-resource whitelist, keyed by the name used in query params.
+type Fields map[string]Field
+
+// Condition is a single filter[field][op]=value.
+type Condition struct {
+	Column string
+	Op     string
+	Value  any
+}
+
+// Order is a single sort key.
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Query is the parsed filter and sort parameters.
+type Query struct {
+	Conditions []Condition
+	Sort       []Order
+}
+
+// Error is returned when a query param is not valid for the resource.
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Param, e.Message)
+}
+
+var filterParam = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// Parse reads filter[...] and sort params from values and validates them against fields.
+func Parse(values url.Values, fields Fields) (*Query, error) {
+	q := &Query{}
+
+	// map order is random, keep conditions in a stable order
+	params := make([]string, 0, len(values))
+	for param := range values {
+		if filterParam.MatchString(param) {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+
+	for _, param := range params {
+		m := filterParam.FindStringSubmatch(param)
+		vals := values[param]
+
+		name, op := m[1], m[2]
+		if op == "" {
+			op = OpEq
+		}
+
+		field, ok := fields[name]
+		if !ok || !field.Filterable {
+			return nil, &Error{Param: param, Message: "filtering by this field is not allowed"}
+		}
+
+		for _, raw := range vals {
+			cond, err := field.condition(name, op, raw)
+			if err != nil {
+				return nil, &Error{Param: param, Message: err.Error()}
+			}
+			q.Conditions = append(q.Conditions, cond)
+		}
+	}
+
+	if sortParam := strings.TrimSpace(values.Get("sort")); sortParam != "" {
+		for _, key := range strings.Split(sortParam, ",") {
+			key = strings.TrimSpace(key)
+			desc := strings.HasPrefix(key, "-")
+			name := strings.TrimPrefix(strings.TrimPrefix(key, "-"), "+")
+
+			field, ok := fields[name]
+			if !ok || !field.Sortable {
+				return nil, &Error{Param: "sort", Message: fmt.Sprintf("sorting by %q is not allowed", name)}
+			}
+			q.Sort = append(q.Sort, Order{Column: field.column(name), Desc: desc})
+		}
+	}
+
+	return q, nil
+}
+
+// Where returns the conditions joined with AND.
+func (q *Query) Where() squirrel.And {
+	where := squirrel.And{}
+	if q == nil {
+		return where
+	}
+
+	for _, c := range q.Conditions {
+		switch c.Op {
+		case OpEq, OpIn:
+			where = append(where, squirrel.Eq{c.Column: c.Value})
+		case OpNe:
+			where = append(where, squirrel.NotEq{c.Column: c.Value})
+		case OpGt:
+			where = append(where, squirrel.Gt{c.Column: c.Value})
+		case OpGte:
+			where = append(where, squirrel.GtOrEq{c.Column: c.Value})
+		case OpLt:
+			where = append(where, squirrel.Lt{c.Column: c.Value})
+		case OpLte:
+			where = append(where, squirrel.LtOrEq{c.Column: c.Value})
+		case OpLike:
+			where = append(where, squirrel.ILike{c.Column: "%" + escapeLike(c.Value.(string)) + "%"})
+		case OpPrefix:
+			where = append(where, squirrel.ILike{c.Column: escapeLike(c.Value.(string)) + "%"})
+		case OpNull:
+			if c.Value.(bool) {
+				where = append(where, squirrel.Eq{c.Column: nil})
+			} else {
+				where = append(where, squirrel.NotEq{c.Column: nil})
+			}
+		}
+	}
+
+	return where
+}
+
+// OrderBy returns the ORDER BY clauses. tieBreaker is appended when it is
+// not already sorted on, so pages are stable.
+func (q *Query) OrderBy(tieBreaker string) []string {
+	if q == nil || len(q.Sort) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(q.Sort)+1)
+	hasTieBreaker := false
+	for _, o := range q.Sort {
+		dir := "ASC"
+		if o.Desc {
+			dir = "DESC"
+		}
+		res = append(res, o.Column+" "+dir)
+		hasTieBreaker = hasTieBreaker || o.Column == tieBreaker
+	}
+	if !hasTieBreaker && tieBreaker != "" {
+		res = append(res, tieBreaker+" ASC")
+	}
+
+	return res
+}
+
+func (f Field) column(name string) string {
+	if f.Column != "" {
+		return f.Column
+	}
+	return name
+}
+
+func (f Field) condition(name, op, raw string) (Condition, error) {
+	cond := Condition{Column: f.column(name), Op: op}
+
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		if f.Type == Bool && op != OpEq && op != OpNe {
+			return cond, fmt.Errorf("operator %q is not supported for boolean fields", op)
+		}
+		value, err := f.parse(raw)
+		if err != nil {
+			return cond, err
+		}
+		cond.Value = value
+	case OpIn:
+		values := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			value, err := f.parse(strings.TrimSpace(item))
+			if err != nil {
+				return cond, err
+			}
+			values = append(values, value)
+		}
+		cond.Value = values
+	case OpLike, OpPrefix:
+		if f.Type != String {
+			return cond, fmt.Errorf("operator %q is supported only for text fields", op)
+		}
+		cond.Value = raw
+	case OpNull:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return cond, fmt.Errorf("value should be true or false")
+		}
+		cond.Value = value
+	default:
+		return cond, fmt.Errorf("unknown operator %q", op)
+	}
+
+	return cond, nil
+}
+
+func (f Field) parse(raw string) (any, error) {
+	switch f.Type {
+	case Int:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value should be an integer")
+		}
+		return v, nil
+	case Float:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value should be a number")
+		}
+		return v, nil
+	case Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("value should be true or false")
+		}
+		return v, nil
+	case Time:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if v, err := time.Parse(layout, raw); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("value should be a date (2006-01-02) or RFC3339 time")
+	default:
+		return raw, nil
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
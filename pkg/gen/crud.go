@@ -0,0 +1,291 @@
+// Package gen generates CRUD scaffolding for new entities.
+//
+// New files are rendered from the Go templates in templates/, existing
+// files (the PostgresI interface, the router) are edited at positions
+// found by parsing them, so no marker comments are needed.
+package gen
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+var templates = template.Must(template.ParseFS(templatesFS, "templates/*.tmpl"))
+
+// Paths of the files the generator reads and edits, relative to the project root.
+const (
+	MigrationsDir = "migrations"
+	ModelsDir     = "models"
+	StorageDir    = "storage/postgres"
+	HandlersDir   = "api/handlers/v1"
+	RepoFile      = "storage/postgres/repo.go"
+	RouterFile    = "api/router.go"
+	PolicyFile    = "config/auth.csv"
+)
+
+var migrationName = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// CRUD generates the migration pair, models, storage methods and handlers of e,
+// adds its methods to PostgresI, registers its routes and casbin policies.
+// Nothing is written when any of the files to be created already exists.
+// It returns the written files.
+func CRUD(root string, e *Entity) ([]string, error) {
+	files := map[string][]byte{}
+
+	version, err := nextMigrationVersion(filepath.Join(root, MigrationsDir))
+	if err != nil {
+		return nil, err
+	}
+	migration := fmt.Sprintf("%06d_create_%s_table", version, e.Name)
+
+	newFiles := []struct {
+		path     string
+		template string
+	}{
+		{filepath.Join(MigrationsDir, migration+".up.sql"), "migration.up.sql.tmpl"},
+		{filepath.Join(MigrationsDir, migration+".down.sql"), "migration.down.sql.tmpl"},
+		{filepath.Join(ModelsDir, e.Name+".go"), "model.go.tmpl"},
+		{filepath.Join(StorageDir, e.Name+".go"), "storage.go.tmpl"},
+		{filepath.Join(HandlersDir, e.Name+".go"), "handler.go.tmpl"},
+	}
+	for _, f := range newFiles {
+		if _, err := os.Stat(filepath.Join(root, f.path)); err == nil {
+			return nil, fmt.Errorf("%s already exists", f.path)
+		}
+
+		files[f.path], err = render(f.template, e, filepath.Ext(f.path) == ".go")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files[RepoFile], err = addInterfaceMethods(filepath.Join(root, RepoFile), e)
+	if err != nil {
+		return nil, err
+	}
+
+	files[RouterFile], err = addRoutes(filepath.Join(root, RouterFile), e)
+	if err != nil {
+		return nil, err
+	}
+
+	files[PolicyFile], err = addPolicies(filepath.Join(root, PolicyFile), e)
+	if err != nil {
+		return nil, err
+	}
+
+	written := make([]string, 0, len(files))
+	for path := range files {
+		written = append(written, path)
+	}
+	sort.Strings(written)
+
+	for _, path := range written {
+		if err := os.WriteFile(filepath.Join(root, path), files[path], 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	return written, nil
+}
+
+func render(name string, e *Entity, goSource bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, e); err != nil {
+		return nil, fmt.Errorf("render %s: %w", name, err)
+	}
+
+	if !goSource {
+		return buf.Bytes(), nil
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format %s: %w", name, err)
+	}
+	return src, nil
+}
+
+func nextMigrationVersion(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	last := 0
+	for _, entry := range entries {
+		m := migrationName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		if v, _ := strconv.Atoi(m[1]); v > last {
+			last = v
+		}
+	}
+
+	return last + 1, nil
+}
+
+// addInterfaceMethods appends the methods of e to the PostgresI interface.
+func addInterfaceMethods(path string, e *Entity) ([]byte, error) {
+	src, fset, file, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var iface *ast.InterfaceType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && spec.Name.Name == "PostgresI" {
+			iface, _ = spec.Type.(*ast.InterfaceType)
+		}
+		return iface == nil
+	})
+	if iface == nil {
+		return nil, fmt.Errorf("%s: PostgresI interface not found", path)
+	}
+
+	for _, method := range iface.Methods.List {
+		for _, name := range method.Names {
+			if name.Name == e.GoName+"Create" {
+				return nil, fmt.Errorf("%s: PostgresI already has %sCreate", path, e.GoName)
+			}
+		}
+	}
+
+	snippet, err := render("interface.tmpl", e, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return insertAt(src, fset.Position(iface.Methods.Closing).Offset, snippet)
+}
+
+// addRoutes registers the routes of e in the New function of the router,
+// right before swagger is set up, or before the return when it is not.
+func addRoutes(path string, e *Entity) ([]byte, error) {
+	src, fset, file, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fn *ast.FuncDecl
+	for _, decl := range file.Decls {
+		if f, ok := decl.(*ast.FuncDecl); ok && f.Name.Name == "New" && f.Recv == nil {
+			fn = f
+		}
+	}
+	if fn == nil || len(fn.Body.List) == 0 {
+		return nil, fmt.Errorf("%s: func New not found", path)
+	}
+
+	var (
+		target = fn.Body.List[len(fn.Body.List)-1]
+		names  = map[string]bool{}
+	)
+	for _, stmt := range fn.Body.List {
+		if assign, ok := stmt.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					names[ident.Name] = true
+				}
+			}
+			if callsPackage(assign, "ginSwagger") && target == fn.Body.List[len(fn.Body.List)-1] {
+				target = stmt
+			}
+		}
+	}
+
+	for _, param := range fn.Type.Params.List {
+		for _, name := range param.Names {
+			names[name.Name] = true
+		}
+	}
+	for _, imp := range file.Imports {
+		if imp.Name != nil {
+			names[imp.Name.Name] = true
+		}
+	}
+	if names[e.VarName] {
+		e.VarName += "Group"
+	}
+	if names[e.VarName] {
+		return nil, fmt.Errorf("%s: %s is already declared in New", path, e.VarName)
+	}
+
+	snippet, err := render("router.tmpl", e, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return insertAt(src, fset.Position(target.Pos()).Offset, snippet)
+}
+
+// addPolicies appends casbin policies of the routes of e.
+func addPolicies(path string, e *Entity) ([]byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := render("policy.tmpl", e, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		src = append(src, '\n')
+	}
+	return append(src, policies...), nil
+}
+
+func parseFile(path string) ([]byte, *token.FileSet, *ast.File, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return src, fset, file, nil
+}
+
+// insertAt inserts snippet into src at offset and gofmts the result.
+func insertAt(src []byte, offset int, snippet []byte) ([]byte, error) {
+	res := make([]byte, 0, len(src)+len(snippet))
+	res = append(res, src[:offset]...)
+	res = append(res, snippet...)
+	res = append(res, src[offset:]...)
+
+	return format.Source(res)
+}
+
+func callsPackage(node ast.Node, pkg string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == pkg {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,67 @@
+package gen
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestCRUDBuilds generates an entity into a copy of the project and checks
+// the result builds and passes vet.
+func TestCRUDBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the whole project")
+	}
+
+	root := t.TempDir()
+	if err := copyProject(filepath.Join("..", ".."), root); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := ParseEntity("blog_post", []string{"title:string", "views:int", "rating:float", "published:bool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CRUD(root, e); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
+// copyProject copies the files of the project at src to dst, leaving out
+// the git history.
+func copyProject(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0o644)
+	})
+}
@@ -0,0 +1,135 @@
+package gen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var identifier = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// fieldTypes maps the types accepted in a field spec to their Go, SQL and filter types.
+var fieldTypes = map[string]struct {
+	GoType     string
+	SQLType    string
+	FilterType string
+}{
+	"string": {"string", "TEXT NOT NULL DEFAULT ''", "String"},
+	"int":    {"int64", "BIGINT NOT NULL DEFAULT 0", "Int"},
+	"float":  {"float64", "DOUBLE PRECISION NOT NULL DEFAULT 0", "Float"},
+	"bool":   {"bool", "BOOLEAN NOT NULL DEFAULT FALSE", "Bool"},
+}
+
+var reservedColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+}
+
+// Entity describes the resource a CRUD is generated for.
+type Entity struct {
+	Name     string // snake_case singular, e.g. blog_post
+	GoName   string // BlogPost
+	VarName  string // blogPost
+	Plural   string // blog_posts, also the table name
+	GoPlural string // BlogPosts
+	Route    string // blog-post
+	Fields   []Field
+}
+
+// Field is a column of the entity besides id and timestamps.
+type Field struct {
+	Name       string // snake_case
+	GoName     string
+	GoType     string
+	SQLType    string
+	FilterType string
+}
+
+// ParseEntity builds an Entity from its name and field specs in the
+// name:type form, e.g. ParseEntity("product", []string{"title:string", "price:float"}).
+// Supported types are string, int, float and bool. When no fields are
+// given the entity gets a single <name>_name string field.
+func ParseEntity(name string, specs []string) (*Entity, error) {
+	if !identifier.MatchString(name) {
+		return nil, fmt.Errorf("invalid entity name %q, use snake_case", name)
+	}
+
+	e := &Entity{
+		Name:    name,
+		GoName:  camel(name, true),
+		VarName: camel(name, false),
+		Plural:  plural(name),
+		Route:   strings.ReplaceAll(name, "_", "-"),
+	}
+	e.GoPlural = camel(e.Plural, true)
+
+	if len(specs) == 0 {
+		specs = []string{name + "_name:string"}
+	}
+
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		fieldName, fieldType, ok := strings.Cut(spec, ":")
+		if !ok {
+			fieldType = "string"
+		}
+
+		t, known := fieldTypes[fieldType]
+		switch {
+		case !identifier.MatchString(fieldName):
+			return nil, fmt.Errorf("invalid field name %q, use snake_case", fieldName)
+		case reservedColumns[fieldName]:
+			return nil, fmt.Errorf("field %q is added automatically", fieldName)
+		case seen[fieldName]:
+			return nil, fmt.Errorf("field %q is given twice", fieldName)
+		case !known:
+			return nil, fmt.Errorf("unknown type %q of field %q, use one of string, int, float, bool", fieldType, fieldName)
+		}
+		seen[fieldName] = true
+
+		e.Fields = append(e.Fields, Field{
+			Name:       fieldName,
+			GoName:     camel(fieldName, true),
+			GoType:     t.GoType,
+			SQLType:    t.SQLType,
+			FilterType: t.FilterType,
+		})
+	}
+
+	return e, nil
+}
+
+// Columns returns the field columns separated by comma.
+func (e *Entity) Columns() string {
+	names := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func camel(s string, upper bool) string {
+	var b strings.Builder
+	for i, part := range strings.Split(s, "_") {
+		switch {
+		case part == "":
+		case i == 0 && !upper:
+			b.WriteString(part)
+		case part == "id" || part == "url":
+			b.WriteString(strings.ToUpper(part))
+		default:
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsAny(s[len(s)-2:len(s)-1], "aeiou"):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
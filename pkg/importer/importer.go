@@ -0,0 +1,308 @@
+// Package importer runs import jobs: it reads the uploaded file, validates
+// its rows and creates the valid ones, saving the progress as it goes.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/tabular"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Worker runs import jobs one at a time. A job whose worker stops is run
+// again by another one after its lease ends, starting after the last
+// saved chunk.
+type Worker struct {
+	Storage   postgres.PostgresI
+	Log       *logger.Logger
+	Interval  time.Duration // pause between polls when there is no job
+	ChunkSize int           // rows created between progress saves
+	Lease     time.Duration
+}
+
+// Run runs jobs until ctx is done, returning once the chunk being imported
+// is finished.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	// a chunk is imported to the end when ctx is canceled meanwhile, the
+	// job is then left for its lease to expire and resumed after the chunk
+	work := etc.WithoutCancel(ctx)
+
+	for {
+		for ctx.Err() == nil {
+			job, err := w.Storage.ImportJobClaim(work, &models.ImportJobClaimReq{Lease: w.Lease})
+			if err != nil {
+				w.Log.Error("import worker: failed to claim a job", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+
+			err = w.run(ctx, work, job)
+			switch {
+			case status.Code(err) == codes.Aborted:
+				// the lease ended and another worker resumed the job
+				w.Log.Warn("import worker: job was claimed by another worker", logger.String("job_id", job.Id))
+			case err != nil:
+				w.Log.Error("import worker: failed to run job", logger.String("job_id", job.Id), err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// row is a record which is ready to be created, or what is wrong with it.
+type row struct {
+	line     int
+	template *models.TemplateContent
+	user     *models.UserImportRow
+	errors   []*models.ImportRowError
+}
+
+// run imports job with work until stop is canceled.
+func (w *Worker) run(stop, ctx context.Context, job *models.ImportJob) error {
+	progress := &models.ImportJobProgressReq{
+		Id:         job.Id,
+		ClaimToken: job.ClaimToken,
+		Status:     models.ImportStatusRunning,
+		Lease:      w.Lease,
+	}
+
+	records, columns, err := tabular.ReadAll(job.Payload, job.Format)
+	if err == nil {
+		err = checkColumns(job, columns)
+	}
+	if err != nil {
+		progress.Status = models.ImportStatusFailed
+		progress.Message = err.Error()
+		return w.Storage.ImportJobProgress(ctx, progress)
+	}
+	progress.TotalRows = len(records)
+	progress.ProcessedRows = job.ProcessedRows
+
+	// changes are audited as made by who uploaded the file
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: job.OwnerSub, Role: job.OwnerRole, RequestId: "import:" + job.Id})
+
+	for start := job.ProcessedRows; start < len(records); start += w.ChunkSize {
+		if stop.Err() != nil {
+			return nil
+		}
+
+		end := start + w.ChunkSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		rows := make([]*row, 0, end-start)
+		for _, record := range records[start:end] {
+			rows = append(rows, parse(job.Kind, record))
+		}
+
+		// the rows and the progress are saved together, so a chunk is
+		// either created and counted or left for the next claim
+		req, addErrors := chunk(job, rows)
+		err = w.Storage.ImportJobChunk(ctx, req, func(res *models.ImportJobChunkResult) *models.ImportJobProgressReq {
+			addErrors(res)
+
+			progress.ProcessedRows = end
+			progress.SucceededRows, progress.FailedRows, progress.Errors = 0, 0, nil
+			for _, r := range rows {
+				if len(r.errors) == 0 {
+					progress.SucceededRows++
+					continue
+				}
+				progress.FailedRows++
+				progress.Errors = append(progress.Errors, r.errors...)
+			}
+			if end == len(records) {
+				progress.Status = models.ImportStatusSucceeded
+			}
+			return progress
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if job.ProcessedRows >= len(records) {
+		progress.Status = models.ImportStatusSucceeded
+		progress.SucceededRows, progress.FailedRows, progress.Errors = 0, 0, nil
+		return w.Storage.ImportJobProgress(ctx, progress)
+	}
+	return nil
+}
+
+// chunk returns the request creating the valid rows and a function adding
+// the errors of the ones which could not be created to them.
+func chunk(job *models.ImportJob, rows []*row) (*models.ImportJobChunkReq, func(*models.ImportJobChunkResult)) {
+	var valid []*row
+	for _, r := range rows {
+		if len(r.errors) == 0 {
+			valid = append(valid, r)
+		}
+	}
+
+	req := &models.ImportJobChunkReq{}
+	switch job.Kind {
+	case models.ImportKindTemplate:
+		req.Templates = &models.TemplateBulkReq{Mode: models.BulkModeBestEffort, OwnerSub: job.OwnerSub}
+		for i, r := range valid {
+			req.Templates.Operations = append(req.Templates.Operations, &models.TemplateBulkOperation{
+				Op:              models.BulkOpCreate,
+				TemplateContent: *r.template,
+				Index:           i,
+			})
+		}
+	case models.ImportKindUser:
+		for _, r := range valid {
+			req.Users = append(req.Users, &models.UserCreateReq{
+				Id:       uuid.New().String(),
+				UserName: r.user.UserName,
+				Email:    r.user.Email,
+			})
+		}
+	}
+
+	addErrors := func(res *models.ImportJobChunkResult) {
+		for _, result := range res.Templates {
+			if result.Err != nil {
+				r := valid[result.Index]
+				r.errors = append(r.errors, &models.ImportRowError{Line: r.line, Message: rowMessage(result.Err)})
+			}
+		}
+		for i, err := range res.Users {
+			if err != nil {
+				r := valid[i]
+				r.errors = append(r.errors, &models.ImportRowError{Line: r.line, Message: rowMessage(err)})
+			}
+		}
+	}
+	return req, addErrors
+}
+
+// required are the columns a CSV file of a kind should have.
+var required = map[string][]string{
+	models.ImportKindTemplate: {"template_name"},
+	models.ImportKindUser:     {"user_name", "email"},
+}
+
+func checkColumns(job *models.ImportJob, columns []string) error {
+	if job.Format != models.FormatCSV {
+		return nil
+	}
+
+	var missing []string
+	for _, column := range required[job.Kind] {
+		found := false
+		for _, c := range columns {
+			found = found || c == column
+		}
+		if !found {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the CSV header has no %s column", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// rowMessage tells why a row could not be created without exposing
+// database details.
+func rowMessage(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+	return "could not be created"
+}
+
+// parse turns record into a row and validates it.
+func parse(kind string, record *tabular.Record) *row {
+	r := &row{line: record.Line}
+	fail := func(field, message string) *row {
+		r.errors = append(r.errors, &models.ImportRowError{Line: r.line, Field: field, Message: message})
+		return r
+	}
+	if record.Err != nil {
+		return fail("", record.Err.Error())
+	}
+
+	switch kind {
+	case models.ImportKindTemplate:
+		r.template = &models.TemplateContent{}
+		if record.JSON != nil {
+			if err := json.Unmarshal(record.JSON, r.template); err != nil {
+				return fail("", "invalid template: "+err.Error())
+			}
+		} else {
+			r.template.TemplateName = record.Fields["template_name"]
+			r.template.Description = record.Fields["description"]
+			r.template.Status = record.Fields["status"]
+			if tags := record.Fields["tags"]; tags != "" {
+				r.template.Tags = strings.Split(tags, ",")
+			}
+			for _, field := range []string{"body", "body_schema"} {
+				value := strings.TrimSpace(record.Fields[field])
+				if value != "" && !json.Valid([]byte(value)) {
+					fail(field, "should be JSON")
+				}
+			}
+			if len(r.errors) > 0 {
+				return r
+			}
+			if body := strings.TrimSpace(record.Fields["body"]); body != "" {
+				r.template.Body = json.RawMessage(body)
+			}
+			if bodySchema := strings.TrimSpace(record.Fields["body_schema"]); bodySchema != "" {
+				r.template.BodySchema = json.RawMessage(bodySchema)
+			}
+		}
+
+		for _, fieldError := range r.template.Validate() {
+			fail(fieldError.Field, fieldError.Message)
+		}
+
+	case models.ImportKindUser:
+		r.user = &models.UserImportRow{}
+		if record.JSON != nil {
+			if err := json.Unmarshal(record.JSON, r.user); err != nil {
+				return fail("", "invalid user: "+err.Error())
+			}
+		} else {
+			r.user.UserName = record.Fields["user_name"]
+			r.user.Email = record.Fields["email"]
+		}
+		r.user.UserName = strings.TrimSpace(r.user.UserName)
+		r.user.Email = strings.ToLower(strings.TrimSpace(r.user.Email))
+
+		if r.user.UserName == "" || utf8.RuneCountInString(r.user.UserName) > 64 {
+			fail("user_name", "should have 1 to 64 characters")
+		}
+		if address, err := mail.ParseAddress(r.user.Email); err != nil || address.Address != r.user.Email || len(r.user.Email) > 64 {
+			fail("email", "should be an email address of at most 64 characters")
+		}
+	}
+
+	return r
+}
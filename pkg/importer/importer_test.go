@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chunkStorage saves progress until the claim is lost after claimedChunks.
+type chunkStorage struct {
+	postgres.PostgresI
+	claimedChunks int
+	progress      []*models.ImportJobProgressReq
+}
+
+func (s *chunkStorage) ImportJobChunk(ctx context.Context, req *models.ImportJobChunkReq, progress func(*models.ImportJobChunkResult) *models.ImportJobProgressReq) error {
+	res := &models.ImportJobChunkResult{Users: make([]error, len(req.Users))}
+	if len(req.Users) > 0 {
+		res.Users[0] = status.Error(codes.AlreadyExists, "Already exists")
+	}
+	p := progress(res)
+
+	if len(s.progress) == s.claimedChunks {
+		return status.Error(codes.Aborted, "Import job has been claimed by another worker")
+	}
+	saved := *p
+	s.progress = append(s.progress, &saved)
+	return nil
+}
+
+func (s *chunkStorage) ImportJobProgress(ctx context.Context, req *models.ImportJobProgressReq) error {
+	saved := *req
+	s.progress = append(s.progress, &saved)
+	return nil
+}
+
+func TestWorkerRun(t *testing.T) {
+	job := &models.ImportJob{
+		Id:         "job",
+		Kind:       models.ImportKindUser,
+		Format:     models.FormatCSV,
+		ClaimToken: "token",
+		Payload: []byte("user_name,email\n" +
+			"a,a@example.com\nb,b@example.com\n" +
+			"c,c@example.com\n,invalid\n" +
+			"e,e@example.com\n"),
+	}
+
+	t.Run("saves each chunk with its progress", func(t *testing.T) {
+		storage := &chunkStorage{claimedChunks: 3}
+		w := &Worker{Storage: storage, Log: logger.New("error"), ChunkSize: 2, Lease: time.Minute}
+
+		if err := w.run(context.Background(), context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(storage.progress) != 3 {
+			t.Fatalf("saved progress %d times, want once per chunk", len(storage.progress))
+		}
+		second := storage.progress[1]
+		if second.ClaimToken != "token" || second.ProcessedRows != 4 || second.SucceededRows != 0 || second.FailedRows != 2 {
+			t.Fatalf("second chunk saved %+v, want 4 processed rows, 2 failed", second)
+		}
+		if last := storage.progress[2]; last.Status != models.ImportStatusSucceeded || last.ProcessedRows != 5 {
+			t.Fatalf("last chunk saved %+v, want all 5 rows processed and succeeded status", last)
+		}
+	})
+
+	t.Run("stops when the claim is lost", func(t *testing.T) {
+		storage := &chunkStorage{claimedChunks: 1}
+		w := &Worker{Storage: storage, Log: logger.New("error"), ChunkSize: 2, Lease: time.Minute}
+
+		err := w.run(context.Background(), context.Background(), job)
+		if status.Code(err) != codes.Aborted {
+			t.Fatalf("run returned %v, want codes.Aborted", err)
+		}
+		if len(storage.progress) != 1 {
+			t.Fatalf("saved progress %d times, want only before the claim was lost", len(storage.progress))
+		}
+	})
+}
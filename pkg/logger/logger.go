@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -17,13 +19,41 @@ type Interface interface {
 	Fatal(message interface{}, args ...interface{})
 }
 
-// Logger -.
+// Logger writes JSON lines to stdout. The args of its methods are not
+// format arguments: a Field is logged under its key, the first error under
+// "error" and the rest under "args".
 type Logger struct {
 	logger *zerolog.Logger
 }
 
 var _ Interface = (*Logger)(nil)
 
+// Field is a key/value pair logged with a message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any returns a field logging value under key.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String returns a field logging value under key.
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int returns a field logging value under key.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration returns a field logging value in milliseconds under key.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: float64(value.Microseconds()) / 1000}
+}
+
 // New -.
 func New(level string) *Logger {
 	var l zerolog.Level
@@ -41,62 +71,106 @@ func New(level string) *Logger {
 		l = zerolog.InfoLevel
 	}
 
-	zerolog.SetGlobalLevel(l)
-
-	skipFrameCount := 3
-	logger := zerolog.New(os.Stdout).With().Timestamp().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + skipFrameCount).Logger()
+	// the methods of Logger and write are between the caller and zerolog
+	skipFrameCount := 2
+	logger := zerolog.New(os.Stdout).Level(l).With().Timestamp().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + skipFrameCount).Logger()
 
 	return &Logger{
 		logger: &logger,
 	}
 }
 
+// With returns a logger adding fields to every line.
+func (l *Logger) With(fields ...Field) *Logger {
+	ctx := l.logger.With()
+	for _, f := range fields {
+		ctx = ctx.Interface(f.Key, f.Value)
+	}
+	logger := ctx.Logger()
+	return &Logger{logger: &logger}
+}
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying l.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// Ctx returns the logger ctx carries, e.g. the one of the request with
+// its id, or l when it carries none.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+			return logger
+		}
+	}
+	return l
+}
+
 // Debug -.
 func (l *Logger) Debug(message interface{}, args ...interface{}) {
-	l.msg("debug", message, args...)
+	l.write(zerolog.DebugLevel, message, args)
 }
 
 // Info -.
 func (l *Logger) Info(message string, args ...interface{}) {
-	l.log(message, args...)
+	l.write(zerolog.InfoLevel, message, args)
 }
 
 // Warn -.
 func (l *Logger) Warn(message string, args ...interface{}) {
-	l.log(message, args...)
+	l.write(zerolog.WarnLevel, message, args)
 }
 
 // Error -.
 func (l *Logger) Error(message interface{}, args ...interface{}) {
-	if l.logger.GetLevel() == zerolog.DebugLevel {
-		l.Debug(message, args...)
-	}
-
-	l.msg("error", message, args...)
+	l.write(zerolog.ErrorLevel, message, args)
 }
 
-// Fatal -.
+// Fatal logs and exits with 1.
 func (l *Logger) Fatal(message interface{}, args ...interface{}) {
-	l.msg("fatal", message, args...)
+	l.write(zerolog.FatalLevel, message, args)
 
 	os.Exit(1)
 }
 
-func (l *Logger) log(message string, args ...interface{}) {
-	if len(args) == 0 {
-		l.logger.Info().Msg(message)
-	} else {
-		l.logger.Info().Msgf(message, args...)
+func (l *Logger) write(level zerolog.Level, message interface{}, args []interface{}) {
+	// WithLevel does not exit or panic on its own
+	event := l.logger.WithLevel(level)
+	if event == nil {
+		return
+	}
+
+	var (
+		rest     []interface{}
+		hasError bool
+	)
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case Field:
+			event = event.Interface(arg.Key, arg.Value)
+		case error:
+			if !hasError {
+				event, hasError = event.Err(arg), true
+				continue
+			}
+			rest = append(rest, arg.Error())
+		case nil:
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) > 0 {
+		event = event.Interface("args", rest)
 	}
-}
 
-func (l *Logger) msg(level string, message interface{}, args ...interface{}) {
 	switch msg := message.(type) {
 	case error:
-		l.log(msg.Error(), args...)
+		event.Msg(msg.Error())
 	case string:
-		l.log(msg, args...)
+		event.Msg(msg)
 	default:
-		l.log(fmt.Sprintf("%s message %v has unknown type %v", level, message, msg), args...)
+		event.Msg(fmt.Sprint(message))
 	}
 }
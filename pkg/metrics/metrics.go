@@ -0,0 +1,147 @@
+// Package metrics keeps the Prometheus metrics of the program, served at
+// /metrics.
+//
+// Requests are labeled by the route template, e.g. /v1/template/:id, and
+// not the raw path, so ids do not create new series.
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Results of a login.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Http requests served by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve http requests by route template, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Http requests being served.",
+	})
+
+	Registrations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "user_registrations_total",
+		Help: "Users registered.",
+	})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_logins_total",
+		Help: "Logins of existing users by result, success or failure (incorrect password).",
+	}, []string{"result"})
+
+	OtpsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "otp_sent_total",
+		Help: "One time passwords emailed by purpose.",
+	}, []string{"purpose"})
+
+	MediaUploads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "media_uploads_total",
+		Help: "Media files uploaded.",
+	})
+
+	MediaUploadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "media_uploaded_bytes_total",
+		Help: "Size of the media files uploaded.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		Registrations,
+		Logins,
+		OtpsSent,
+		MediaUploads,
+		MediaUploadedBytes,
+	)
+}
+
+// Register registers the collectors reading the state of the dependencies
+// with the default registry: the stats of the db pool, of the redis pool
+// unless it is nil, and of the read cache.
+func Register(db *sql.DB, pool *redis.Pool, cacheStats func() []*models.CacheStats) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+	if pool != nil {
+		prometheus.MustRegister(&redisPoolCollector{pool: pool})
+	}
+	prometheus.MustRegister(&cacheCollector{stats: cacheStats})
+}
+
+var (
+	redisActiveDesc = prometheus.NewDesc("redis_pool_active_connections",
+		"Connections of the redis pool, idle or in use.", nil, nil)
+	redisIdleDesc = prometheus.NewDesc("redis_pool_idle_connections",
+		"Idle connections of the redis pool.", nil, nil)
+	redisWaitCountDesc = prometheus.NewDesc("redis_pool_wait_total",
+		"Times a connection was waited for because the pool was exhausted.", nil, nil)
+	redisWaitDurationDesc = prometheus.NewDesc("redis_pool_wait_duration_seconds_total",
+		"Time spent waiting for a connection.", nil, nil)
+)
+
+// redisPoolCollector reads the stats of a redis pool when scraped.
+type redisPoolCollector struct {
+	pool *redis.Pool
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisActiveDesc
+	ch <- redisIdleDesc
+	ch <- redisWaitCountDesc
+	ch <- redisWaitDurationDesc
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(redisActiveDesc, prometheus.GaugeValue, float64(stats.ActiveCount))
+	ch <- prometheus.MustNewConstMetric(redisIdleDesc, prometheus.GaugeValue, float64(stats.IdleCount))
+	ch <- prometheus.MustNewConstMetric(redisWaitCountDesc, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(redisWaitDurationDesc, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+var (
+	cacheHitsDesc = prometheus.NewDesc("cache_hits_total",
+		"Reads served from the read cache by entity.", []string{"entity"}, nil)
+	cacheMissesDesc = prometheus.NewDesc("cache_misses_total",
+		"Reads loaded from the database by entity.", []string{"entity"}, nil)
+	cacheErrorsDesc = prometheus.NewDesc("cache_errors_total",
+		"Failed reads and writes of the read cache by entity.", []string{"entity"}, nil)
+)
+
+// cacheCollector reads the counters the read cache keeps when scraped.
+type cacheCollector struct {
+	stats func() []*models.CacheStats
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheErrorsDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.stats() {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(s.Hits), s.Entity)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(s.Misses), s.Entity)
+		ch <- prometheus.MustNewConstMetric(cacheErrorsDesc, prometheus.CounterValue, float64(s.Errors), s.Entity)
+	}
+}
@@ -0,0 +1,155 @@
+// Package migration applies the sql migrations embedded into the binary.
+//
+// Versions are tracked in the schema_migrations table, the same one the
+// migrate CLI uses, so databases migrated with the CLI keep working.
+// Every change of the schema runs under a postgres advisory lock, so only
+// one instance migrates at a time when several of them start together.
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // postgres driver
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/migrations"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/db"
+)
+
+// ErrSchemaBehind is returned by Check when the database is not migrated
+// to the latest embedded version.
+var ErrSchemaBehind = errors.New("database schema is behind the expected version")
+
+// ErrNoSteps is returned by Down when it is not given a positive number of
+// migrations to roll back, DownAll has to be called to roll back all of them.
+var ErrNoSteps = errors.New("number of migrations to roll back should be positive")
+
+// Migrator -.
+type Migrator struct {
+	m   *migrate.Migrate
+	src source.Driver
+}
+
+// Status -.
+type Status struct {
+	Version uint   // applied version, 0 when nothing is applied
+	Dirty   bool   // the last migration failed in the middle
+	Latest  uint   // the version this binary expects
+	Pending []uint // versions not applied yet
+}
+
+// New opens its own connection to the database, closed by Close.
+func New(cfg config.Config) (*Migrator, error) {
+	return NewWithSource(cfg, migrations.FS)
+}
+
+// NewWithSource is like New but reads migrations from fsys.
+func NewWithSource(cfg config.Config, fsys fs.FS) (*Migrator, error) {
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migration - New - iofs.New: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, db.ConnString(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("migration - New - migrate.NewWithSourceInstance: %w", err)
+	}
+
+	return &Migrator{m: m, src: src}, nil
+}
+
+// Up applies n pending migrations, all of them when n is 0.
+func (m *Migrator) Up(n int) error {
+	if n > 0 {
+		return ignoreNoChange(m.m.Steps(n))
+	}
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down rolls back n migrations, n should be positive.
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return ErrNoSteps
+	}
+	return ignoreNoChange(m.m.Steps(-n))
+}
+
+// DownAll rolls back every applied migration, dropping the whole schema.
+func (m *Migrator) DownAll() error {
+	return ignoreNoChange(m.m.Down())
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Force sets version without running migrations and clears the dirty flag.
+// It is used to recover after a failed migration was fixed by hand.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Status -.
+func (m *Migrator) Status() (*Status, error) {
+	st := &Status{}
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
+	}
+	st.Version, st.Dirty = version, dirty
+
+	v, err := m.src.First()
+	for err == nil {
+		st.Latest = v
+		if v > st.Version {
+			st.Pending = append(st.Pending, v)
+		}
+		v, err = m.src.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// Check returns ErrSchemaBehind when there are pending migrations or the
+// last one failed.
+func (m *Migrator) Check() error {
+	st, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	if st.Dirty {
+		return fmt.Errorf("%w: version %d is dirty", ErrSchemaBehind, st.Version)
+	}
+	if st.Version < st.Latest {
+		return fmt.Errorf("%w: database is at version %d, expected %d", ErrSchemaBehind, st.Version, st.Latest)
+	}
+
+	return nil
+}
+
+// Close -.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+// Package otp issues the one time passwords users are sent to prove they
+// own an email address.
+//
+// Codes are scoped by purpose, so a code sent for registration can not
+// reset a password, and only their HMAC is stored. A code is accepted once,
+// guessing it is limited to a number of attempts and a new one can be
+// requested only after a cooldown.
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+)
+
+// Purposes codes are issued for.
+const (
+	PurposeRegister       = "register"
+	PurposeForgotPassword = "forgot-password"
+)
+
+const codeLength = 6
+
+var (
+	ErrNotFound        = errors.New("otp: not found or expired")
+	ErrIncorrect       = errors.New("otp: incorrect")
+	ErrTooManyAttempts = errors.New("otp: too many attempts")
+	ErrCooldown        = errors.New("otp: issued recently")
+)
+
+type Service interface {
+	// Issue returns a new code for recipient, replacing the previous one.
+	// ErrCooldown is returned when the previous one was issued less than
+	// the cooldown ago.
+	Issue(ctx context.Context, purpose, recipient string) (string, error)
+	// Revoke drops the code of recipient and its cooldown, e.g. when it
+	// could not be sent, so a new one can be issued right away.
+	Revoke(ctx context.Context, purpose, recipient string) error
+	// Check tells whether code is the current code of recipient without
+	// using it up.
+	Check(ctx context.Context, purpose, recipient, code string) error
+	// Verify is Check which uses the code up, so it is accepted only once.
+	Verify(ctx context.Context, purpose, recipient, code string) error
+	// Cooldown is how long Issue refuses new codes for a recipient.
+	Cooldown() time.Duration
+}
+
+type service struct {
+	store       redisrepo.InMemoryStorageI
+	secret      []byte
+	ttl         time.Duration
+	cooldown    time.Duration
+	maxAttempts int64
+}
+
+// New returns the service keeping codes in store under otp:<purpose>.
+func New(store redisrepo.InMemoryStorageI, cfg config.Config) Service {
+	return &service{
+		store:       store,
+		secret:      []byte(cfg.OtpSecret),
+		ttl:         time.Duration(cfg.OtpTimeout) * time.Second,
+		cooldown:    time.Duration(cfg.OtpResendCooldown) * time.Second,
+		maxAttempts: int64(cfg.OtpMaxAttempts),
+	}
+}
+
+func (s *service) Issue(ctx context.Context, purpose, recipient string) (code string, err error) {
+	store, recipient := s.scope(purpose, recipient)
+
+	if s.cooldown > 0 {
+		var issued int64
+		issued, err = store.Incr(ctx, "cooldown:"+recipient, s.cooldown)
+		if err != nil {
+			return "", err
+		}
+		if issued > 1 {
+			return "", ErrCooldown
+		}
+
+		// a code which was not issued does not hold back the next one
+		defer func() {
+			if err != nil {
+				_, delErr := store.Del(etc.WithoutCancel(ctx), "cooldown:"+recipient)
+				err = errors.Join(err, delErr)
+			}
+		}()
+	}
+
+	code = etc.GenerateCode(codeLength)
+	if code == "" {
+		return "", errors.New("otp: generating code failed")
+	}
+
+	// attempts at the previous code do not count against the new one
+	if _, err := store.Del(ctx, "attempts:"+recipient); err != nil {
+		return "", err
+	}
+	if err := store.Set(ctx, "code:"+recipient, s.hash(purpose, recipient, code), s.ttl); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (s *service) Revoke(ctx context.Context, purpose, recipient string) error {
+	store, recipient := s.scope(purpose, recipient)
+
+	_, err := store.Del(ctx, "code:"+recipient, "attempts:"+recipient, "cooldown:"+recipient)
+	return err
+}
+
+func (s *service) Check(ctx context.Context, purpose, recipient, code string) error {
+	return s.verify(ctx, purpose, recipient, code, false)
+}
+
+func (s *service) Verify(ctx context.Context, purpose, recipient, code string) error {
+	return s.verify(ctx, purpose, recipient, code, true)
+}
+
+func (s *service) Cooldown() time.Duration {
+	return s.cooldown
+}
+
+func (s *service) verify(ctx context.Context, purpose, recipient, code string, use bool) error {
+	store, recipient := s.scope(purpose, recipient)
+	codeKey, attemptsKey := "code:"+recipient, "attempts:"+recipient
+
+	hash, err := store.GetString(ctx, codeKey)
+	if err == redisrepo.ErrNotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	attempts, err := store.Incr(ctx, attemptsKey, s.ttl)
+	if err != nil {
+		return err
+	}
+	if attempts > s.maxAttempts {
+		_, err := store.Del(ctx, codeKey, attemptsKey)
+		return errors.Join(ErrTooManyAttempts, err)
+	}
+
+	if !hmac.Equal([]byte(hash), []byte(s.hash(purpose, recipient, code))) {
+		return ErrIncorrect
+	}
+	if !use {
+		return nil
+	}
+
+	// only the request which deletes the code may use it
+	deleted, err := store.Del(ctx, codeKey, attemptsKey)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scope returns the store of purpose and recipient normalized.
+func (s *service) scope(purpose, recipient string) (redisrepo.InMemoryStorageI, string) {
+	return redisrepo.Namespaced(s.store, "otp:"+purpose), strings.ToLower(strings.TrimSpace(recipient))
+}
+
+func (s *service) hash(purpose, recipient, code string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose + "\x00" + recipient + "\x00" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+)
+
+// failingStore fails the next Set when failSet is true.
+type failingStore struct {
+	redisrepo.InMemoryStorageI
+	failSet bool
+}
+
+func (s *failingStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if s.failSet {
+		s.failSet = false
+		return errors.New("set failed")
+	}
+	return s.InMemoryStorageI.Set(ctx, key, value, ttl)
+}
+
+func TestIssueCooldown(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{InMemoryStorageI: redisrepo.NewMemoryRepo()}
+	s := New(store, config.Config{OtpSecret: "secret", OtpTimeout: 60, OtpMaxAttempts: 3, OtpResendCooldown: 60})
+
+	store.failSet = true
+	if _, err := s.Issue(ctx, PurposeRegister, "a@example.com"); err == nil {
+		t.Fatal("Issue succeeded while the store failed")
+	}
+	code, err := s.Issue(ctx, PurposeRegister, "a@example.com")
+	if err != nil {
+		t.Fatalf("Issue after a failed one returned %v, want a new code", err)
+	}
+
+	if _, err := s.Issue(ctx, PurposeRegister, "a@example.com"); err != ErrCooldown {
+		t.Fatalf("Issue within the cooldown returned %v, want ErrCooldown", err)
+	}
+
+	// the code could not be sent
+	if err := s.Revoke(ctx, PurposeRegister, "a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(ctx, PurposeRegister, "a@example.com", code); err != ErrNotFound {
+		t.Fatalf("Check of a revoked code returned %v, want ErrNotFound", err)
+	}
+	if _, err := s.Issue(ctx, PurposeRegister, "a@example.com"); err != nil {
+		t.Fatalf("Issue after Revoke returned %v, want a new code", err)
+	}
+}
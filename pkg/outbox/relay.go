@@ -0,0 +1,79 @@
+// Package outbox publishes events stored in the outbox table to an events.Sink.
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+)
+
+// Relay polls the outbox and publishes due events to the sink. An event is
+// marked as published only after the sink accepted it, so it can be
+// published more than once if the relay stops in between.
+type Relay struct {
+	Storage     postgres.PostgresI
+	Sink        events.Sink
+	Log         *logger.Logger
+	Interval    time.Duration // pause between polls when the outbox is drained
+	BatchSize   int
+	MaxAttempts int // an event failing this many times is dead and never published
+	MaxBackoff  time.Duration
+}
+
+// Run publishes events until ctx is done, returning once the batch being
+// published is finished.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	// a batch is published to the end when ctx is canceled meanwhile
+	work := etc.WithoutCancel(ctx)
+
+	for {
+		// keep draining while there is a backlog
+		for ctx.Err() == nil {
+			n, err := r.Storage.OutboxPublish(work, &models.OutboxPublishReq{
+				Limit:       r.BatchSize,
+				MaxAttempts: r.MaxAttempts,
+				MaxBackoff:  r.MaxBackoff,
+			}, r.publish)
+			if err != nil {
+				r.Log.Error("outbox relay: failed to publish events", err)
+				break
+			}
+			if n < r.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, e *models.OutboxEvent) error {
+	err := r.Sink.Publish(ctx, events.Event{
+		Id:            e.Id,
+		Type:          e.EventType,
+		AggregateType: e.AggregateType,
+		AggregateId:   e.AggregateId,
+		Payload:       e.Payload,
+		OccurredAt:    e.CreatedAt,
+	})
+	switch {
+	case err == nil:
+	case e.Attempts+1 >= r.MaxAttempts:
+		r.Log.Error("outbox relay: event is dead, it will not be published", logger.String("event_type", e.EventType), logger.Any("event_id", e.Id), logger.Int("attempts", e.Attempts+1), err)
+	default:
+		r.Log.Warn("outbox relay: event was not published", logger.String("event_type", e.EventType), logger.Any("event_id", e.Id), err)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+// Package requestid identifies the request a piece of work is done for,
+// so the log lines, audit events and responses of one request can be
+// matched.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the header request ids are read from and responded with.
+const Header = "X-Request-ID"
+
+// maxLength bounds the ids taken from clients.
+const maxLength = 128
+
+type ctxKey struct{}
+
+// New returns a new request id.
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid tells whether an id sent by a client can be used: not empty, not
+// too long and printable ASCII only, so it can not break log lines or
+// headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// WithContext returns a copy of ctx carrying id.
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the id ctx carries or "".
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
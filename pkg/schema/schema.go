@@ -0,0 +1,80 @@
+// Package schema validates JSON documents against JSON Schemas given by users.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Violation is a part of a document which does not match the schema.
+type Violation struct {
+	Field   string // dotted path of the value, e.g. body.items.0.name
+	Message string
+}
+
+// ErrInvalidSchema is returned when the schema itself is not valid.
+var ErrInvalidSchema = errors.New("invalid JSON Schema")
+
+// Validate validates document against schema and returns the violations
+// with field paths prefixed by field. Schemas can not reference remote
+// documents.
+func Validate(schema, document json.RawMessage, field string) ([]Violation, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("remote reference %s is not allowed", url)
+	}
+
+	if err := compiler.AddResource("mem:///body_schema.json", bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	compiled, err := compiler.Compile("mem:///body_schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []Violation{{Field: field, Message: "invalid JSON"}}, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	err = compiled.Validate(value)
+	if errors.As(err, &validationErr) {
+		return violations(validationErr, field), nil
+	}
+
+	return nil, err
+}
+
+// violations flattens the leaves of err, which say what is wrong where.
+func violations(err *jsonschema.ValidationError, field string) []Violation {
+	if len(err.Causes) == 0 {
+		return []Violation{{Field: path(field, err.InstanceLocation), Message: err.Message}}
+	}
+
+	var res []Violation
+	for _, cause := range err.Causes {
+		res = append(res, violations(cause, field)...)
+	}
+	return res
+}
+
+// path turns a JSON pointer into a dotted path under field.
+func path(field, pointer string) string {
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		field += "." + token
+	}
+	return field
+}
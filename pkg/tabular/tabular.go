@@ -0,0 +1,171 @@
+// Package tabular reads and writes rows as CSV with a header line or as
+// NDJSON, one JSON object per line.
+package tabular
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+)
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	if format == models.FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes rows as CSV, starting with a header of the column names,
+// or as NDJSON.
+type Writer struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewWriter returns a writer to w. The CSV header is buffered until the
+// first Flush like the rows are.
+func NewWriter(w io.Writer, format string, columns []string) *Writer {
+	if format == models.FormatNDJSON {
+		return &Writer{json: json.NewEncoder(w)}
+	}
+
+	res := &Writer{csv: csv.NewWriter(w)}
+	_ = res.csv.Write(columns) // errors are kept until Flush
+	return res
+}
+
+// Write writes a row, cells in the order of the columns for CSV and value
+// marshaled as JSON for NDJSON.
+func (w *Writer) Write(cells []string, value any) error {
+	if w.json != nil {
+		return w.json.Encode(value)
+	}
+
+	safe := make([]string, len(cells))
+	for i, cell := range cells {
+		safe[i] = escapeCell(cell)
+	}
+	return w.csv.Write(safe)
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Record is a row read from a file. Fields has the cells of a CSV row by
+// column name, JSON the object of an NDJSON line.
+type Record struct {
+	Line   int
+	Fields map[string]string
+	JSON   json.RawMessage
+	Err    error // the row could not be read
+}
+
+// ReadAll reads the rows of data. Unreadable rows are returned with Err
+// set, the error is only returned when data can not be read at all. The
+// CSV columns are returned too.
+func ReadAll(data []byte, format string) ([]*Record, []string, error) {
+	if format == models.FormatNDJSON {
+		return readNDJSON(data)
+	}
+	return readCSV(data)
+}
+
+func readCSV(data []byte) ([]*Record, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var records []*Record
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			records = append(records, &Record{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(cells) != len(header) {
+			records = append(records, &Record{Line: line, Err: fmt.Errorf("has %d cells, the header has %d", len(cells), len(header))})
+			continue
+		}
+
+		fields := make(map[string]string, len(header))
+		for i, column := range header {
+			fields[column] = unescapeCell(cells[i])
+		}
+		records = append(records, &Record{Line: line, Fields: fields})
+	}
+
+	return records, header, nil
+}
+
+func readNDJSON(data []byte) ([]*Record, []string, error) {
+	var (
+		records []*Record
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+		line    int
+	)
+	scanner.Buffer(make([]byte, 0, 64<<10), len(data)+1)
+
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		if !json.Valid(text) || text[0] != '{' {
+			records = append(records, &Record{Line: line, Err: errors.New("should be a JSON object")})
+			continue
+		}
+		records = append(records, &Record{Line: line, JSON: append(json.RawMessage{}, text...)})
+	}
+
+	return records, nil, scanner.Err()
+}
+
+// escapeCell keeps spreadsheets from running cells which look like
+// formulas by prefixing them with a quote.
+func escapeCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCell undoes escapeCell, so exported files can be imported back.
+func unescapeCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"::ffff:93.184.216.34", true},
+
+		{"0.1.2.3", false},         // this network
+		{"10.1.2.3", false},        // private
+		{"100.64.0.1", false},      // shared address space
+		{"100.127.255.254", false}, // shared address space
+		{"127.0.0.1", false},       // loopback
+		{"169.254.169.254", false}, // link-local
+		{"172.16.0.1", false},      // private
+		{"192.0.0.8", false},       // IETF protocol assignments
+		{"192.0.2.1", false},       // documentation
+		{"192.88.99.1", false},     // 6to4 relay anycast
+		{"192.168.1.1", false},     // private
+		{"198.18.0.1", false},      // benchmarking
+		{"198.19.255.254", false},  // benchmarking
+		{"198.51.100.1", false},    // documentation
+		{"203.0.113.1", false},     // documentation
+		{"224.0.0.1", false},       // multicast
+		{"239.255.255.250", false}, // multicast
+		{"240.0.0.1", false},       // reserved
+		{"255.255.255.255", false}, // broadcast
+		{"::", false},              // unspecified
+		{"::1", false},             // loopback
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.1.2.3", false},
+		{"64:ff9b::a01:203", false}, // NAT64 of 10.1.2.3
+		{"64:ff9b:1::1", false},     // local NAT64
+		{"100::1", false},           // discard
+		{"2001::1", false},          // Teredo
+		{"2001:db8::1", false},      // documentation
+		{"2002:a01:203::1", false},  // 6to4 of 10.1.2.3
+		{"fd00::1", false},          // unique local
+		{"fe80::1", false},          // link-local
+		{"ff02::1", false},          // multicast
+		{"ff0e::1", false},          // global multicast
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("invalid ip %s", tt.ip)
+			}
+			if got := isPublic(ip); got != tt.want {
+				t.Fatalf("isPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
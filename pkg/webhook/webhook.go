@@ -0,0 +1,97 @@
+// Package webhook delivers domain events to the HTTP endpoints users subscribe.
+//
+// Every request carries the headers
+//
+//	X-Webhook-Id:        id of the delivery, the same for all attempts
+//	X-Webhook-Event:     event type, e.g. template.updated
+//	X-Webhook-Signature: t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>" keyed by the secret>
+//
+// Receivers should recompute the signature and reject old timestamps.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+)
+
+// EventTypes are the events webhooks can subscribe to.
+var EventTypes = []string{
+	events.TemplateCreated,
+	events.TemplateUpdated,
+	events.TemplateDeleted,
+}
+
+// IsEventType reports whether webhooks can subscribe to t.
+func IsEventType(t string) bool {
+	for _, eventType := range EventTypes {
+		if eventType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-Webhook-Signature header of body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sink creates webhook deliveries for published events. It is meant to be
+// one of the sinks of the outbox relay.
+type Sink struct {
+	Storage postgres.PostgresI
+}
+
+// Publish -.
+func (s *Sink) Publish(ctx context.Context, event events.Event) error {
+	if !IsEventType(event.Type) {
+		return nil
+	}
+
+	// the payload is the template as it was, it is the only place the owner
+	// of a deleted template is left
+	var template struct {
+		OwnerSub string `json:"owner_sub"`
+	}
+	if err := json.Unmarshal(event.Payload, &template); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.Storage.WebhookEnqueue(ctx, &models.WebhookEnqueueReq{
+		EventId:    event.Id,
+		EventType:  event.Type,
+		Payload:    body,
+		TemplateId: event.AggregateId,
+		OwnerSub:   template.OwnerSub,
+	})
+}
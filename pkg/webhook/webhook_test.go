@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+)
+
+type enqueueRecorder struct {
+	postgres.PostgresI
+	reqs []*models.WebhookEnqueueReq
+}
+
+func (r *enqueueRecorder) WebhookEnqueue(ctx context.Context, req *models.WebhookEnqueueReq) error {
+	r.reqs = append(r.reqs, req)
+	return nil
+}
+
+func TestSinkPublish(t *testing.T) {
+	storage := &enqueueRecorder{}
+	sink := &Sink{Storage: storage}
+
+	event := events.Event{
+		Id:            7,
+		Type:          events.TemplateDeleted,
+		AggregateType: "template",
+		AggregateId:   "5f0c6f4e-3f0a-4a53-9b1e-2f6d0c1b7a10",
+		Payload:       json.RawMessage(`{"id":"5f0c6f4e-3f0a-4a53-9b1e-2f6d0c1b7a10","owner_sub":"owner"}`),
+	}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Publish(context.Background(), events.Event{Type: events.UserRegistered, Payload: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.reqs) != 1 {
+		t.Fatalf("enqueued %d events, want only the template one", len(storage.reqs))
+	}
+	req := storage.reqs[0]
+	if req.EventId != event.Id || req.TemplateId != event.AggregateId || req.OwnerSub != "owner" {
+		t.Fatalf("enqueued %+v, want the template and owner of the event", req)
+	}
+}
@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+)
+
+// Worker sends due deliveries and records the results. Failed deliveries
+// are retried with exponential backoff until MaxAttempts is reached.
+type Worker struct {
+	Storage     postgres.PostgresI
+	Client      *http.Client
+	Log         *logger.Logger
+	Interval    time.Duration // pause between polls when nothing is due
+	BatchSize   int           // deliveries sent at once
+	MaxAttempts int
+	MaxBackoff  time.Duration
+}
+
+// Run sends deliveries until ctx is done, returning once the batch being
+// sent is finished.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	// a batch is delivered to the end when ctx is canceled meanwhile
+	work := etc.WithoutCancel(ctx)
+
+	for {
+		for ctx.Err() == nil {
+			n, err := w.deliverBatch(work)
+			if err != nil {
+				w.Log.Error("webhook worker: failed to deliver webhooks", err)
+				break
+			}
+			if n < w.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) deliverBatch(ctx context.Context) (int, error) {
+	deliveries, err := w.Storage.WebhookDeliveryClaim(ctx, &models.WebhookDeliveryClaimReq{
+		Limit: w.BatchSize,
+		// a delivery still running after the lease would be sent twice
+		Lease: 2 * w.Client.Timeout,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	wg := sync.WaitGroup{}
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			w.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (w *Worker) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	start := time.Now()
+	code, err := w.send(ctx, delivery)
+
+	record := &models.WebhookDeliveryRecordReq{
+		Id:           delivery.Id,
+		ResponseCode: code,
+		Duration:     time.Since(start),
+		Succeeded:    err == nil,
+		MaxAttempts:  w.MaxAttempts,
+		MaxBackoff:   w.MaxBackoff,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := w.Storage.WebhookDeliveryRecord(ctx, record); err != nil {
+		w.Log.Error("webhook worker: failed to record delivery", logger.Any("delivery_id", delivery.Id), err)
+	}
+}
+
+// send posts the delivery and returns the response status code, or 0 when
+// there was no response.
+func (w *Worker) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "webhook-delivery/1.0")
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drain a bit of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,246 @@
+// Package cache keeps single entity reads of postgres.PostgresI in Redis.
+//
+// Entries are deleted when the entity is changed through the same
+// PostgresI and expire after a TTL otherwise, which bounds how stale a
+// read can be when the database is changed around it. Concurrent misses of
+// the same key are loaded from the database once.
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/etc"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+	"golang.org/x/sync/singleflight"
+)
+
+// Entities which can be cached.
+const (
+	EntityTemplate = "template"
+	EntityUser     = "user"
+)
+
+// Repo is a postgres.PostgresI caching TemplateGet and UserGet by id.
+// Users are cached without their password hash and refresh token, so
+// UserGet by id never returns them while the user cache is enabled.
+type Repo struct {
+	postgres.PostgresI
+
+	log       *logger.Logger
+	templates *entity
+	users     *entity
+}
+
+// New wraps pg with the caches cfg enables, keeping entries in store.
+func New(pg postgres.PostgresI, store redisrepo.InMemoryStorageI, log *logger.Logger, cfg config.Config) *Repo {
+	return &Repo{
+		PostgresI: pg,
+		log:       log,
+		templates: newEntity(store, EntityTemplate, cfg.CacheTemplateEnabled, cfg.CacheTemplateTTL, cfg.ContextTimeout),
+		users:     newEntity(store, EntityUser, cfg.CacheUserEnabled, cfg.CacheUserTTL, cfg.ContextTimeout),
+	}
+}
+
+// Stats returns the hits and misses of every entity since the start.
+func (r *Repo) Stats() []*models.CacheStats {
+	return []*models.CacheStats{r.templates.stats(), r.users.stats()}
+}
+
+func (r *Repo) TemplateGet(ctx context.Context, req *models.TemplateGetReq) (*models.TemplateResponse, error) {
+	if !r.templates.enabled || req.Id == "" {
+		return r.PostgresI.TemplateGet(ctx, req)
+	}
+
+	return get(ctx, r.templates, r.log, req.Id, func(ctx context.Context) (*models.TemplateResponse, error) {
+		return r.PostgresI.TemplateGet(ctx, req)
+	})
+}
+
+func (r *Repo) TemplateUpdate(ctx context.Context, req *models.TemplateUpdateReq) (*models.TemplateResponse, error) {
+	res, err := r.PostgresI.TemplateUpdate(ctx, req)
+	r.templates.invalidate(ctx, r.log, err, req.Id)
+	return res, err
+}
+
+func (r *Repo) TemplateDelete(ctx context.Context, req *models.TemplateDeleteReq) error {
+	err := r.PostgresI.TemplateDelete(ctx, req)
+	r.templates.invalidate(ctx, r.log, err, req.Id)
+	return err
+}
+
+func (r *Repo) TemplateRestore(ctx context.Context, req *models.TemplateRestoreReq) (*models.TemplateResponse, error) {
+	res, err := r.PostgresI.TemplateRestore(ctx, req)
+	r.templates.invalidate(ctx, r.log, err, req.TemplateId)
+	return res, err
+}
+
+func (r *Repo) TemplateBulk(ctx context.Context, req *models.TemplateBulkReq) ([]*models.TemplateBulkResult, error) {
+	res, err := r.PostgresI.TemplateBulk(ctx, req)
+
+	var ids []string
+	for _, op := range req.Operations {
+		if op.Op != models.BulkOpCreate {
+			ids = append(ids, op.Id)
+		}
+	}
+	// every item may have failed, deleting the entries anyway is cheap
+	r.templates.invalidate(ctx, r.log, nil, ids...)
+
+	return res, err
+}
+
+func (r *Repo) UserGet(ctx context.Context, req *models.UserGetReq) (*models.UserResponse, error) {
+	if !r.users.enabled || req.Id == "" {
+		return r.PostgresI.UserGet(ctx, req)
+	}
+
+	return get(ctx, r.users, r.log, req.Id, func(ctx context.Context) (*models.UserResponse, error) {
+		user, err := r.PostgresI.UserGet(ctx, req)
+		if err != nil {
+			return user, err
+		}
+
+		// credentials are not kept in the cache
+		withoutSecrets := *user
+		withoutSecrets.Password, withoutSecrets.RefreshToken = "", ""
+		return &withoutSecrets, nil
+	})
+}
+
+func (r *Repo) UserUpdate(ctx context.Context, req *models.UserUpdateReq) (*models.UserResponse, error) {
+	res, err := r.PostgresI.UserUpdate(ctx, req)
+	r.users.invalidate(ctx, r.log, err, req.Id)
+	return res, err
+}
+
+func (r *Repo) UserPasswordUpdate(ctx context.Context, req *models.UserPasswordUpdateReq) error {
+	err := r.PostgresI.UserPasswordUpdate(ctx, req)
+	r.users.invalidate(ctx, r.log, err, req.Id)
+	return err
+}
+
+func (r *Repo) UserDelete(ctx context.Context, req *models.UserDeleteReq) error {
+	err := r.PostgresI.UserDelete(ctx, req)
+	r.users.invalidate(ctx, r.log, err, req.Id)
+	return err
+}
+
+func (r *Repo) UpdateSingleField(ctx context.Context, req *models.UpdateSingleFieldReq) error {
+	err := r.PostgresI.UpdateSingleField(ctx, req)
+
+	id, _ := req.Id.(string)
+	switch req.Table {
+	case "templates":
+		r.templates.invalidate(ctx, r.log, err, id)
+	case "users":
+		r.users.invalidate(ctx, r.log, err, id)
+	}
+
+	return err
+}
+
+// entity is the cache of one kind of entity.
+type entity struct {
+	name    string
+	enabled bool
+	ttl     time.Duration
+	timeout time.Duration // of a load shared by concurrent misses
+	store   redisrepo.InMemoryStorageI
+	group   singleflight.Group
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func newEntity(store redisrepo.InMemoryStorageI, name string, enabled bool, ttl, timeout int) *entity {
+	return &entity{
+		name:    name,
+		enabled: enabled,
+		ttl:     time.Duration(ttl) * time.Second,
+		timeout: time.Duration(timeout) * time.Second,
+		store:   redisrepo.Namespaced(store, "cache:"+name),
+	}
+}
+
+// get returns the cached entry of id, loading it with load when it is not
+// cached. Cache errors are logged and the database is read instead. A load
+// is shared by the callers missing the same id, so it runs within
+// e.timeout whether or not the caller starting it is still waiting.
+func get[T any](ctx context.Context, e *entity, log *logger.Logger, id string, load func(context.Context) (*T, error)) (*T, error) {
+	cached := new(T)
+	err := e.store.GetJSON(ctx, id, cached)
+	if err == nil {
+		e.hits.Add(1)
+		return cached, nil
+	}
+	if err != redisrepo.ErrNotFound {
+		e.errors.Add(1)
+		log.Ctx(ctx).Error("cache: reading", logger.String("entity", e.name), logger.String("id", id), err)
+	}
+	e.misses.Add(1)
+
+	ch := e.group.DoChan(id, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(etc.WithoutCancel(ctx), e.timeout)
+		defer cancel()
+
+		value, err := load(loadCtx)
+		if err != nil {
+			return value, err
+		}
+
+		if err := e.store.SetJSON(loadCtx, id, value, e.ttl); err != nil {
+			e.errors.Add(1)
+			log.Ctx(ctx).Error("cache: writing", logger.String("entity", e.name), logger.String("id", id), err)
+		}
+		return value, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-ch:
+	}
+	loaded, _ := result.Val.(*T)
+	if result.Err != nil || loaded == nil {
+		return loaded, result.Err
+	}
+
+	// callers waiting for the same load get a copy each
+	res := *loaded
+	return &res, nil
+}
+
+// invalidate deletes the entries of ids unless the change failed with err.
+func (e *entity) invalidate(ctx context.Context, log *logger.Logger, err error, ids ...string) {
+	if !e.enabled || err != nil || len(ids) == 0 {
+		return
+	}
+
+	if _, err := e.store.Del(ctx, ids...); err != nil {
+		e.errors.Add(1)
+		log.Ctx(ctx).Error("cache: invalidating", logger.String("entity", e.name), logger.Any("ids", ids), err)
+	}
+}
+
+func (e *entity) stats() *models.CacheStats {
+	res := &models.CacheStats{
+		Entity:     e.name,
+		Enabled:    e.enabled,
+		TTLSeconds: int(e.ttl.Seconds()),
+		Hits:       e.hits.Load(),
+		Misses:     e.misses.Load(),
+		Errors:     e.errors.Load(),
+	}
+	if total := res.Hits + res.Misses; total > 0 {
+		res.HitRatio = float64(res.Hits) / float64(total)
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+)
+
+func TestGetCanceledCaller(t *testing.T) {
+	store := redisrepo.NewMemoryRepo()
+	e := newEntity(store, EntityTemplate, true, 60, 5)
+	log := logger.New("error")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	once := sync.Once{}
+	load := func(ctx context.Context) (*models.TemplateResponse, error) {
+		once.Do(func() { close(started) })
+		<-release
+		// the load must not be canceled with the caller which started it
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &models.TemplateResponse{Id: "id"}, nil
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := get(first, e, log, "id", load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan error)
+	go func() {
+		res, err := get(context.Background(), e, log, "id", load)
+		if err == nil && res.Id != "id" {
+			t.Errorf("got %+v, want the loaded template", res)
+		}
+		second <- err
+	}()
+	// the second caller is waiting for the load of the first one
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; err != context.Canceled {
+		t.Fatalf("canceled caller got %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatalf("waiting caller got %v, want the loaded template", err)
+	}
+
+	cached := &models.TemplateResponse{}
+	if err := e.store.GetJSON(context.Background(), "id", cached); err != nil || cached.Id != "id" {
+		t.Fatalf("cached %+v, %v, want the loaded template", cached, err)
+	}
+}
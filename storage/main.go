@@ -2,26 +2,41 @@ package storage
 
 import (
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/db"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/cache"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/postgres"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
 )
 
 type StorageI interface {
 	Postgres() postgres.PostgresI
+	CacheStats() []*models.CacheStats
+	QueryStats() []*models.QueryStats
 }
 
 type StoragePg struct {
-	postgres postgres.PostgresI
+	db       *db.Postgres
+	postgres *cache.Repo
 }
 
 // NewStoragePg
-func New(db *db.Postgres, log *logger.Logger, cfg config.Config) StorageI {
+func New(db *db.Postgres, log *logger.Logger, cfg config.Config, inMemory redisrepo.InMemoryStorageI) StorageI {
 	return &StoragePg{
-		postgres: postgres.New(db, log, cfg),
+		db:       db,
+		postgres: cache.New(postgres.New(db, log, cfg), inMemory, log, cfg),
 	}
 }
 
 func (s *StoragePg) Postgres() postgres.PostgresI {
 	return s.postgres
 }
+
+func (s *StoragePg) CacheStats() []*models.CacheStats {
+	return s.postgres.Stats()
+}
+
+func (s *StoragePg) QueryStats() []*models.QueryStats {
+	return s.db.QueryStats()
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+)
+
+// change describes a mutation of a resource. It is recorded by afterChange
+// in the transaction of the mutation.
+type change struct {
+	action       string // audit action
+	event        string // domain event published through the outbox
+	resourceType string
+	resourceId   string
+	before       any // nil on create
+	after        any // nil on delete
+}
+
+// afterChange records side effects of c using tx, so they are committed
+// or rolled back together with the mutation itself.
+func (r *postgresRepo) afterChange(ctx context.Context, tx squirrel.BaseRunner, c change) error {
+	err := r.insertAuditEvent(ctx, tx, &models.AuditEventCreateReq{
+		Action:       c.action,
+		ResourceType: c.resourceType,
+		ResourceId:   c.resourceId,
+		Diff:         audit.Diff(c.before, c.after),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.event != "" {
+		return r.insertOutboxEvent(ctx, tx, c)
+	}
+	return nil
+}
+
+// AuditEventCreate records an event which doesn't change any data, e.g. a login.
+func (r *postgresRepo) AuditEventCreate(ctx context.Context, req *models.AuditEventCreateReq) error {
+	err := r.insertAuditEvent(ctx, r.Db.Db, req)
+	return HandleDatabaseError(ctx, err, r.Log, "AuditEventCreate: r.insertAuditEvent()")
+}
+
+func (r *postgresRepo) insertAuditEvent(ctx context.Context, runner squirrel.BaseRunner, req *models.AuditEventCreateReq) error {
+	actor := audit.ActorFrom(ctx)
+
+	diff, err := json.Marshal(req.Diff)
+	if err != nil {
+		return err
+	}
+	if req.Diff == nil {
+		diff = []byte("{}")
+	}
+
+	_, err = r.Db.Builder.Insert("audit_events").Columns(
+		"actor_sub, actor_role, ip, user_agent, request_id, action, resource_type, resource_id, diff",
+	).Values(
+		actor.Sub, actor.Role, actor.IP, actor.UserAgent, actor.RequestId,
+		req.Action, req.ResourceType, req.ResourceId, diff,
+	).RunWith(runner).ExecContext(ctx)
+
+	return err
+}
+
+func (r *postgresRepo) AuditEventFind(ctx context.Context, req *models.AuditEventFindReq) (*models.AuditEventFindResponse, error) {
+	var (
+		res            = &models.AuditEventFindResponse{}
+		whereCondition = squirrel.And{}
+		createdAt      time.Time
+	)
+
+	for column, value := range map[string]string{
+		"actor_sub":     req.ActorSub,
+		"action":        req.Action,
+		"resource_type": req.ResourceType,
+		"resource_id":   req.ResourceId,
+	} {
+		if value != "" {
+			whereCondition = append(whereCondition, squirrel.Eq{column: value})
+		}
+	}
+	if !req.From.IsZero() {
+		whereCondition = append(whereCondition, squirrel.GtOrEq{"created_at": req.From})
+	}
+	if !req.To.IsZero() {
+		whereCondition = append(whereCondition, squirrel.Lt{"created_at": req.To})
+	}
+
+	countQuery := r.Db.Builder.Select("count(1) as count").From("audit_events").Where(whereCondition)
+	err := countQuery.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(&res.Count)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "AuditEventFind: countQuery.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	query := r.Db.Builder.Select(
+		"id, actor_sub, actor_role, ip, user_agent, request_id, action, resource_type, resource_id, diff, created_at",
+	).From("audit_events").Where(whereCondition).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "AuditEventFind: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			temp = &models.AuditEventResponse{}
+			diff []byte
+		)
+		err := rows.Scan(
+			&temp.Id, &temp.ActorSub, &temp.ActorRole,
+			&temp.Ip, &temp.UserAgent, &temp.RequestId,
+			&temp.Action, &temp.ResourceType, &temp.ResourceId,
+			&diff, &createdAt,
+		)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "AuditEventFind: rows.Scan()")
+		}
+
+		if err := json.Unmarshal(diff, &temp.Diff); err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "AuditEventFind: json.Unmarshal(diff)")
+		}
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		res.Events = append(res.Events, temp)
+	}
+
+	return res, nil
+}
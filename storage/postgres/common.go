@@ -4,17 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
 )
 
+// Ping checks the database can be reached.
+func (p *postgresRepo) Ping(ctx context.Context) error {
+	return p.Db.Db.PingContext(ctx)
+}
+
 func (p *postgresRepo) CheckIfExists(ctx context.Context, req *models.CheckIfExistsReq) (*models.CheckIfExistsRes, error) {
 	var (
 		res sql.NullBool
 	)
 	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s='%s') AS value_exists", req.Table, req.Column, req.Value)
-	err := p.Db.Db.QueryRow(query).Scan(&res)
-	err = HandleDatabaseError(err, p.Log, "CheckIfExists")
+	err := p.Db.Db.QueryRowContext(ctx, query).Scan(&res)
+	err = HandleDatabaseError(ctx, err, p.Log, "CheckIfExists")
 
 	return &models.CheckIfExistsRes{
 		Exists: res.Bool,
@@ -24,6 +30,34 @@ func (p *postgresRepo) CheckIfExists(ctx context.Context, req *models.CheckIfExi
 func (p *postgresRepo) UpdateSingleField(ctx context.Context, req *models.UpdateSingleFieldReq) error {
 	query := fmt.Sprintf("UPDATE %s SET %s=$1 where id=$2", req.Table, req.Column)
 
-	_, err := p.Db.Db.Exec(query, req.NewValue, req.Id)
-	return HandleDatabaseError(err, p.Log, "UpdateSingleField")
+	_, err := p.Db.Db.ExecContext(ctx, query, req.NewValue, req.Id)
+	return HandleDatabaseError(ctx, err, p.Log, "UpdateSingleField")
+}
+
+// withTx runs fn in a transaction which is committed when fn returns nil
+// and rolled back otherwise.
+func (p *postgresRepo) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := p.Db.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// retryBackoff returns how long to wait before the next attempt after
+// attempts failed ones: 1s, 2s, 4s... up to max.
+func retryBackoff(attempts int, max time.Duration) time.Duration {
+	if attempts > 30 {
+		return max
+	}
+	if backoff := time.Second << attempts; backoff < max {
+		return backoff
+	}
+	return max
 }
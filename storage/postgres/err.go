@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
@@ -9,11 +10,20 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func HandleDatabaseError(err error, log *logger.Logger, message string) error {
+// HandleDatabaseError logs err with the logger of ctx and converts it to
+// the status the handlers respond with.
+func HandleDatabaseError(ctx context.Context, err error, log *logger.Logger, message string) error {
 	if err == nil {
 		return nil
 	}
-	log.Error(message + ": " + err.Error())
+	switch err {
+	case sql.ErrNoRows:
+		// an expected outcome rather than a failure
+		log.Ctx(ctx).Debug(message, err)
+	default:
+		log.Ctx(ctx).Error(message, err)
+	}
+
 	switch err {
 	case sql.ErrNoRows:
 		return status.Error(codes.NotFound, "This information is not exists.")
@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (r *postgresRepo) ImportJobCreate(ctx context.Context, req *models.ImportJobCreateReq) (*models.ImportJobResponse, error) {
+	query := r.Db.Builder.Insert("import_jobs").Columns(
+		"id, kind, format, owner_sub, owner_role, payload",
+	).Values(
+		uuid.New().String(), req.Kind, req.Format, req.OwnerSub, req.OwnerRole, req.Payload,
+	).Suffix("RETURNING " + importJobColumns)
+
+	res, err := scanImportJob(query.RunWith(r.Db.Db).QueryRowContext(ctx))
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "ImportJobCreate: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) ImportJobGet(ctx context.Context, req *models.ImportJobGetReq) (*models.ImportJobResponse, error) {
+	query := r.Db.Builder.Select(importJobColumns).From("import_jobs").
+		Where(squirrel.Eq{"id": req.Id, "owner_sub": req.OwnerSub})
+
+	res, err := scanImportJob(query.RunWith(r.Db.Db).QueryRowContext(ctx))
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "ImportJobGet: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+// errImportJobClaimLost is returned when progress is saved for a job which
+// was claimed by another worker since, e.g. after the lease ended.
+var errImportJobClaimLost = status.Error(codes.Aborted, "Import job has been claimed by another worker")
+
+// ImportJobClaim claims the oldest pending job, or a running one whose
+// worker has stopped extending its lease. It returns nil when there is none.
+// The job is given a new claim token, the previous worker can no longer
+// save its progress.
+func (r *postgresRepo) ImportJobClaim(ctx context.Context, req *models.ImportJobClaimReq) (*models.ImportJob, error) {
+	res := &models.ImportJob{}
+	err := r.Db.Db.QueryRowContext(ctx, `
+		UPDATE import_jobs SET status = 'running', locked_until = NOW() + $1 * INTERVAL '1 millisecond',
+			claim_token = $2, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM import_jobs
+			WHERE status = 'pending' OR status = 'running' AND locked_until < NOW()
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, format, owner_sub, owner_role, payload, processed_rows, claim_token`,
+		req.Lease.Milliseconds(), uuid.New().String(),
+	).Scan(&res.Id, &res.Kind, &res.Format, &res.OwnerSub, &res.OwnerRole, &res.Payload, &res.ProcessedRows, &res.ClaimToken)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, HandleDatabaseError(ctx, err, r.Log, "ImportJobClaim: r.Db.Db.QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+// ImportJobProgress saves the progress of a claimed job. Jobs which are
+// finished lose their payload. It fails with codes.Aborted when the job is
+// no longer claimed with req.ClaimToken.
+func (r *postgresRepo) ImportJobProgress(ctx context.Context, req *models.ImportJobProgressReq) error {
+	err := r.importJobProgress(ctx, r.Db.Db, req)
+	return HandleDatabaseError(ctx, err, r.Log, "ImportJobProgress: r.importJobProgress()")
+}
+
+// ImportJobChunk creates the rows of a chunk of a claimed job and saves the
+// progress returned by progress for the result in one transaction, so the
+// rows of a chunk are never created again by the worker resuming the job.
+// Nothing is created when the job is no longer claimed with the token of
+// the progress, it fails with codes.Aborted then.
+func (r *postgresRepo) ImportJobChunk(ctx context.Context, req *models.ImportJobChunkReq, progress func(*models.ImportJobChunkResult) *models.ImportJobProgressReq) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) (err error) {
+		res := &models.ImportJobChunkResult{}
+
+		if req.Templates != nil && len(req.Templates.Operations) > 0 {
+			res.Templates, err = r.templateBulk(ctx, tx, req.Templates)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, user := range req.Users {
+			err := savepoint(ctx, tx, func() error {
+				_, err := r.userCreate(ctx, tx, user)
+				return err
+			})
+			if err != nil {
+				err = HandleDatabaseError(ctx, err, r.Log, "ImportJobChunk: r.userCreate()")
+			}
+			res.Users = append(res.Users, err)
+		}
+
+		return r.importJobProgress(ctx, tx, progress(res))
+	})
+	return HandleDatabaseError(ctx, err, r.Log, "ImportJobChunk: r.withTx()")
+}
+
+func (r *postgresRepo) importJobProgress(ctx context.Context, runner squirrel.BaseRunner, req *models.ImportJobProgressReq) error {
+	if req.Errors == nil {
+		req.Errors = []*models.ImportRowError{}
+	}
+	rowErrors, err := json.Marshal(req.Errors)
+	if err != nil {
+		return err
+	}
+
+	query := r.Db.Builder.Update("import_jobs").
+		Set("status", req.Status).
+		Set("total_rows", req.TotalRows).
+		Set("processed_rows", req.ProcessedRows).
+		Set("succeeded_rows", squirrel.Expr("succeeded_rows + ?", req.SucceededRows)).
+		Set("failed_rows", squirrel.Expr("failed_rows + ?", req.FailedRows)).
+		Set("errors", squirrel.Expr(`COALESCE((
+			SELECT jsonb_agg(e ORDER BY n) FROM (
+				SELECT e, n FROM jsonb_array_elements(errors || ?::jsonb) WITH ORDINALITY t (e, n) ORDER BY n LIMIT ?
+			) s
+		), '[]')`, string(rowErrors), models.ImportMaxErrors)).
+		Set("message", req.Message).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": req.Id, "claim_token": req.ClaimToken})
+
+	if req.Status == models.ImportStatusSucceeded || req.Status == models.ImportStatusFailed {
+		query = query.Set("payload", nil).Set("locked_until", nil).Set("finished_at", time.Now())
+	} else {
+		query = query.Set("locked_until", squirrel.Expr("NOW() + ? * INTERVAL '1 millisecond'", req.Lease.Milliseconds()))
+	}
+
+	result, err := query.RunWith(runner).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errImportJobClaimLost
+	}
+	return nil
+}
+
+const importJobColumns = `id, kind, format, status, total_rows, processed_rows, succeeded_rows, failed_rows,
+	errors, message, created_at, updated_at, finished_at`
+
+func scanImportJob(row squirrel.RowScanner) (*models.ImportJobResponse, error) {
+	var (
+		res                  = &models.ImportJobResponse{}
+		rowErrors            []byte
+		finishedAt           sql.NullTime
+		createdAt, updatedAt time.Time
+	)
+
+	err := row.Scan(
+		&res.Id, &res.Kind, &res.Format, &res.Status,
+		&res.TotalRows, &res.ProcessedRows, &res.SucceededRows, &res.FailedRows,
+		&rowErrors, &res.Message, &createdAt, &updatedAt, &finishedAt,
+	)
+	if err != nil {
+		return res, err
+	}
+
+	if err := json.Unmarshal(rowErrors, &res.Errors); err != nil {
+		return res, err
+	}
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+	if finishedAt.Valid {
+		res.FinishedAt = finishedAt.Time.Format(time.RFC1123)
+	}
+
+	return res, nil
+}
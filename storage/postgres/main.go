@@ -1,18 +1,11 @@
 package postgres
 
 import (
-	"time"
-
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/db"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
 )
 
-var (
-	CreatedAt time.Time
-	UpdatedAt time.Time
-)
-
 type postgresRepo struct {
 	Db  *db.Postgres
 	Log *logger.Logger
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/db"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/logger"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/migration"
+)
+
+// newTestRepo returns a repo on the database POSTGRES_* point to, migrated
+// to the latest version. Tests using it are skipped when POSTGRES_HOST is
+// not set.
+func newTestRepo(t *testing.T) *postgresRepo {
+	t.Helper()
+	if os.Getenv("POSTGRES_HOST") == "" {
+		t.Skip("POSTGRES_HOST is not set")
+	}
+
+	cfg := config.Load()
+	m, err := migration.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	pg, err := db.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pg.Close)
+
+	return &postgresRepo{Db: pg, Log: logger.New("error"), Cfg: cfg}
+}
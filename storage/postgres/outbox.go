@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+)
+
+// insertOutboxEvent stores the event of c, it is published by the relay
+// after tx commits.
+func (r *postgresRepo) insertOutboxEvent(ctx context.Context, tx squirrel.BaseRunner, c change) error {
+	state := c.after
+	if state == nil {
+		state = c.before
+	}
+
+	payload, err := events.Payload(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Db.Builder.Insert("outbox_events").Columns(
+		"aggregate_type, aggregate_id, event_type, payload",
+	).Values(c.resourceType, c.resourceId, c.event, []byte(payload)).RunWith(tx).ExecContext(ctx)
+
+	return err
+}
+
+// OutboxPublish passes due events to publish and records the results. Only
+// the oldest unpublished event of each aggregate is taken, so events of an
+// aggregate are published in order even when one of them is retried. An
+// event failing req.MaxAttempts times is marked dead and skipped, so it
+// does not hold back the later events of its aggregate.
+// Events are locked until the results are recorded, so several relays can
+// run at once. It returns how many events were taken.
+func (r *postgresRepo) OutboxPublish(ctx context.Context, req *models.OutboxPublishReq, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error) {
+	query := r.Db.Builder.Select("o.id, o.aggregate_type, o.aggregate_id, o.event_type, o.payload, o.attempts, o.created_at").
+		From("outbox_events o").
+		Where("o.published_at IS NULL AND o.dead_at IS NULL AND o.next_attempt_at <= NOW()").
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events p WHERE p.aggregate_type = o.aggregate_type
+			AND p.aggregate_id = o.aggregate_id AND p.published_at IS NULL AND p.dead_at IS NULL AND p.id < o.id)`).
+		OrderBy("o.id").
+		Limit(uint64(req.Limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	taken := 0
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := query.RunWith(tx).QueryContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		batch := []*models.OutboxEvent{}
+		for rows.Next() {
+			event := &models.OutboxEvent{}
+			err := rows.Scan(
+				&event.Id, &event.AggregateType, &event.AggregateId,
+				&event.EventType, &event.Payload, &event.Attempts, &event.CreatedAt,
+			)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, event)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		taken = len(batch)
+
+		for _, event := range batch {
+			update := r.Db.Builder.Update("outbox_events").Where(squirrel.Eq{"id": event.Id})
+
+			if err := publish(ctx, event); err != nil {
+				update = update.
+					Set("attempts", event.Attempts+1).
+					Set("last_error", err.Error())
+				if event.Attempts+1 >= req.MaxAttempts {
+					update = update.Set("dead_at", time.Now())
+				} else {
+					update = update.Set("next_attempt_at", time.Now().Add(retryBackoff(event.Attempts, req.MaxBackoff)))
+				}
+			} else {
+				update = update.Set("published_at", time.Now())
+			}
+
+			if _, err := update.RunWith(tx).ExecContext(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return taken, HandleDatabaseError(ctx, err, r.Log, "OutboxPublish")
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/google/uuid"
+)
+
+func TestOutboxPublishDead(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+
+	aggregateId := uuid.New().String()
+	for _, eventType := range []string{"test.failing", "test.next"} {
+		_, err := r.Db.Db.Exec("INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type) VALUES ('test', $1, $2)", aggregateId, eventType)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { r.Db.Db.Exec("DELETE FROM outbox_events WHERE aggregate_id = $1", aggregateId) })
+
+	req := &models.OutboxPublishReq{Limit: 1000, MaxAttempts: 2}
+	var published []string
+	publish := func(ctx context.Context, event *models.OutboxEvent) error {
+		if event.AggregateId != aggregateId {
+			// only the events created here are published
+			return errors.New("not this test")
+		}
+		if event.EventType == "test.failing" {
+			return errors.New("failed")
+		}
+		published = append(published, event.EventType)
+		return nil
+	}
+
+	for attempt := 1; attempt <= req.MaxAttempts; attempt++ {
+		if _, err := r.OutboxPublish(ctx, req, publish); err != nil {
+			t.Fatal(err)
+		}
+		if len(published) != 0 {
+			t.Fatalf("published %v after %d attempts of the first event, want it to hold back the next one", published, attempt)
+		}
+		// the next attempt is due right away
+		_, err := r.Db.Db.Exec("UPDATE outbox_events SET next_attempt_at = NOW() WHERE aggregate_id = $1", aggregateId)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var deadAt *time.Time
+	err := r.Db.Db.QueryRow("SELECT dead_at FROM outbox_events WHERE aggregate_id = $1 AND event_type = 'test.failing'", aggregateId).Scan(&deadAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deadAt == nil {
+		t.Fatalf("event is not dead after %d failed attempts", req.MaxAttempts)
+	}
+
+	if _, err := r.OutboxPublish(ctx, req, publish); err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 1 || published[0] != "test.next" {
+		t.Fatalf("published %v, want the event after the dead one", published)
+	}
+}
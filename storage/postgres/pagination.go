@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/cursor"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/filter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keysetPage paginates rows ordered by (created_at, id), newest first.
+type keysetPage struct {
+	signKey string
+	limit   int
+	query   string         // fingerprint of the where condition
+	cur     *cursor.Cursor // nil on the first page
+}
+
+// newKeysetPage returns the page rawCursor points at among the rows matching
+// where. A cursor issued for another where condition is rejected.
+func (r *postgresRepo) newKeysetPage(rawCursor string, limit int, q *filter.Query, where squirrel.Sqlizer) (*keysetPage, error) {
+	if q != nil && !(len(q.Sort) == 0 || len(q.Sort) == 1 && q.Sort[0] == filter.Order{Column: "created_at", Desc: true}) {
+		return nil, status.Error(codes.InvalidArgument, "cursor pagination supports only sort=-created_at")
+	}
+
+	query, err := queryFingerprint(where)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &keysetPage{
+		signKey: r.Cfg.CursorSignKey,
+		limit:   limit,
+		query:   query,
+	}
+
+	if rawCursor != "" {
+		c, err := cursor.Decode(p.signKey, rawCursor)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+		if c.Query != p.query {
+			return nil, status.Error(codes.InvalidArgument, "cursor was issued for other filters")
+		}
+		p.cur = c
+	}
+
+	return p, nil
+}
+
+func (p *keysetPage) backward() bool {
+	return p.cur != nil && p.cur.Backward
+}
+
+// apply adds the cursor condition and ordering to query. One extra row is
+// requested to find out whether there is a page after this one.
+func (p *keysetPage) apply(query squirrel.SelectBuilder) squirrel.SelectBuilder {
+	switch {
+	case p.cur == nil:
+		query = query.OrderBy("created_at DESC", "id DESC")
+	case p.cur.Backward:
+		query = query.Where("(created_at, id) > (?, ?)", p.cur.CreatedAt, p.cur.Id).
+			OrderBy("created_at ASC", "id ASC")
+	default:
+		query = query.Where("(created_at, id) < (?, ?)", p.cur.CreatedAt, p.cur.Id).
+			OrderBy("created_at DESC", "id DESC")
+	}
+
+	return query.Limit(uint64(p.limit) + 1)
+}
+
+// cursors takes the sort keys of the fetched rows in query order and returns
+// how many rows belong to the page and the cursors of neighbouring pages.
+// When the page was fetched backward, the caller must reverse its rows
+// after trimming them to n.
+func (p *keysetPage) cursors(keys []cursor.Cursor) (n int, next, prev string) {
+	n = len(keys)
+	hasMore := n > p.limit
+	if hasMore {
+		n = p.limit
+		keys = keys[:n]
+	}
+	if n == 0 {
+		return 0, "", ""
+	}
+
+	if p.backward() {
+		reverse(keys)
+	}
+	first, last := keys[0], keys[n-1]
+	first.Backward = true
+	first.Query, last.Query = p.query, p.query
+
+	switch {
+	case p.cur == nil:
+		if hasMore {
+			next = cursor.Encode(p.signKey, last)
+		}
+	case p.cur.Backward:
+		next = cursor.Encode(p.signKey, last)
+		if hasMore {
+			prev = cursor.Encode(p.signKey, first)
+		}
+	default:
+		prev = cursor.Encode(p.signKey, first)
+		if hasMore {
+			next = cursor.Encode(p.signKey, last)
+		}
+	}
+
+	return n, next, prev
+}
+
+// queryFingerprint returns a short hash of the sql and args of where.
+func queryFingerprint(where squirrel.Sqlizer) (string, error) {
+	sql, args, err := where.ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s %v", sql, args)))
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
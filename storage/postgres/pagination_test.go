@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/cursor"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeysetPageFilters(t *testing.T) {
+	r := &postgresRepo{Cfg: config.Config{CursorSignKey: "key"}}
+	where := squirrel.And{squirrel.Eq{"status": "active"}}
+
+	first, err := r.newKeysetPage("", 1, nil, where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, next, _ := first.cursors([]cursor.Cursor{{CreatedAt: time.Now(), Id: "b"}, {CreatedAt: time.Now(), Id: "a"}})
+	if next == "" {
+		t.Fatal("no next cursor")
+	}
+
+	if _, err := r.newKeysetPage(next, 1, nil, squirrel.And{squirrel.Eq{"status": "active"}}); err != nil {
+		t.Fatalf("cursor rejected with the same filters: %v", err)
+	}
+	_, err = r.newKeysetPage(next, 1, nil, squirrel.And{squirrel.Eq{"status": "archived"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("cursor with other filters returned %v, want codes.InvalidArgument", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 type PostgresI interface {
 	// common
+	Ping(ctx context.Context) error
 	UpdateSingleField(ctx context.Context, req *models.UpdateSingleFieldReq) error
 	CheckIfExists(ctx context.Context, req *models.CheckIfExistsReq) (*models.CheckIfExistsRes, error)
 
@@ -16,14 +17,55 @@ type PostgresI interface {
 	UserGet(ctx context.Context, req *models.UserGetReq) (*models.UserResponse, error)
 	UserFind(ctx context.Context, req *models.UserFindReq) (*models.UserFindResponse, error)
 	UserUpdate(ctx context.Context, req *models.UserUpdateReq) (*models.UserResponse, error)
+	UserPasswordUpdate(ctx context.Context, req *models.UserPasswordUpdateReq) error
 	UserDelete(ctx context.Context, req *models.UserDeleteReq) error
+	UserExport(ctx context.Context, req *models.UserFindReq, fn func(*models.UserResponse) error) error
 
-	// Template 
+	// Template
 	TemplateCreate(ctx context.Context, req *models.TemplateCreateReq) (*models.TemplateResponse, error)
 	TemplateGet(ctx context.Context, req *models.TemplateGetReq) (*models.TemplateResponse, error)
 	TemplateFind(ctx context.Context, req *models.TemplateFindReq) (*models.TemplateFindResponse, error)
 	TemplateUpdate(ctx context.Context, req *models.TemplateUpdateReq) (*models.TemplateResponse, error)
 	TemplateDelete(ctx context.Context, req *models.TemplateDeleteReq) error
-	
-	// Don't delete this line, it is used to modify the file automatically
+	TemplateRevisionFind(ctx context.Context, req *models.TemplateRevisionFindReq) (*models.TemplateRevisionFindResponse, error)
+	TemplateRevisionGet(ctx context.Context, req *models.TemplateRevisionGetReq) (*models.TemplateRevisionResponse, error)
+	TemplateRevisionDiff(ctx context.Context, req *models.TemplateRevisionDiffReq) (*models.TemplateRevisionDiffResponse, error)
+	TemplateRestore(ctx context.Context, req *models.TemplateRestoreReq) (*models.TemplateResponse, error)
+	TemplateAccess(ctx context.Context, req *models.TemplateAccessReq) (*models.TemplateAccessResponse, error)
+	TemplateGrant(ctx context.Context, req *models.TemplateGrantReq) (*models.TemplateCollaboratorResponse, error)
+	TemplateGrantFind(ctx context.Context, req *models.TemplateGrantFindReq) (*models.TemplateCollaboratorFindResponse, error)
+	TemplateGrantDelete(ctx context.Context, req *models.TemplateGrantDeleteReq) error
+	TemplateShareLinkCreate(ctx context.Context, req *models.TemplateShareLinkCreateReq) (*models.TemplateShareLinkResponse, error)
+	TemplateShareLinkFind(ctx context.Context, req *models.TemplateShareLinkFindReq) (*models.TemplateShareLinkFindResponse, error)
+	TemplateShareLinkDelete(ctx context.Context, req *models.TemplateShareLinkDeleteReq) error
+	TemplateSharedGet(ctx context.Context, req *models.TemplateSharedGetReq) (*models.TemplateResponse, error)
+	TemplateExport(ctx context.Context, req *models.TemplateFindReq, fn func(*models.TemplateResponse) error) error
+	TemplateBulk(ctx context.Context, req *models.TemplateBulkReq) ([]*models.TemplateBulkResult, error)
+
+	// Audit
+	AuditEventCreate(ctx context.Context, req *models.AuditEventCreateReq) error
+	AuditEventFind(ctx context.Context, req *models.AuditEventFindReq) (*models.AuditEventFindResponse, error)
+
+	// Webhook
+	WebhookCreate(ctx context.Context, req *models.WebhookCreateReq) (*models.WebhookResponse, error)
+	WebhookGet(ctx context.Context, req *models.WebhookGetReq) (*models.WebhookResponse, error)
+	WebhookFind(ctx context.Context, req *models.WebhookFindReq) (*models.WebhookFindResponse, error)
+	WebhookUpdate(ctx context.Context, req *models.WebhookUpdateReq) (*models.WebhookResponse, error)
+	WebhookDelete(ctx context.Context, req *models.WebhookDeleteReq) error
+	WebhookDeliveryFind(ctx context.Context, req *models.WebhookDeliveryFindReq) (*models.WebhookDeliveryFindResponse, error)
+	WebhookDeliveryGet(ctx context.Context, req *models.WebhookDeliveryGetReq) (*models.WebhookDeliveryResponse, error)
+	WebhookRedeliver(ctx context.Context, req *models.WebhookDeliveryGetReq) (*models.WebhookDeliveryResponse, error)
+	WebhookEnqueue(ctx context.Context, req *models.WebhookEnqueueReq) error
+	WebhookDeliveryClaim(ctx context.Context, req *models.WebhookDeliveryClaimReq) ([]*models.WebhookDelivery, error)
+	WebhookDeliveryRecord(ctx context.Context, req *models.WebhookDeliveryRecordReq) error
+
+	// Import
+	ImportJobCreate(ctx context.Context, req *models.ImportJobCreateReq) (*models.ImportJobResponse, error)
+	ImportJobGet(ctx context.Context, req *models.ImportJobGetReq) (*models.ImportJobResponse, error)
+	ImportJobClaim(ctx context.Context, req *models.ImportJobClaimReq) (*models.ImportJob, error)
+	ImportJobProgress(ctx context.Context, req *models.ImportJobProgressReq) error
+	ImportJobChunk(ctx context.Context, req *models.ImportJobChunkReq, progress func(*models.ImportJobChunkResult) *models.ImportJobProgressReq) error
+
+	// Outbox
+	OutboxPublish(ctx context.Context, req *models.OutboxPublishReq, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error)
 }
@@ -2,37 +2,107 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/cursor"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const templateColumns = "id, template_name, description, body, body_schema, status, owner_sub, created_at, updated_at, version"
+
+// templateTagsColumn selects the sorted tag names of templates.
+const templateTagsColumn = `COALESCE((SELECT array_agg(tg.name ORDER BY tg.name) FROM template_tags tt
+	JOIN tags tg ON tg.id = tt.tag_id WHERE tt.template_id = templates.id), '{}') AS tags`
+
 func (r *postgresRepo) TemplateCreate(ctx context.Context, req *models.TemplateCreateReq) (*models.TemplateResponse, error) {
-	res := &models.TemplateResponse{}
+	var res []*models.TemplateResponse
+	err := r.withTx(ctx, func(tx *sql.Tx) (err error) {
+		res, err = r.templateCreate(ctx, tx, []*models.TemplateCreateReq{req})
+		return err
+	})
+	if err != nil {
+		return &models.TemplateResponse{}, HandleDatabaseError(ctx, err, r.Log, "TemplateCreate: query.RunWith(tx).Scan()")
+	}
+
+	return res[0], nil
+}
+
+// templateCreate inserts the templates with one statement and returns them
+// in the order of reqs.
+func (r *postgresRepo) templateCreate(ctx context.Context, tx *sql.Tx, reqs []*models.TemplateCreateReq) ([]*models.TemplateResponse, error) {
 	query := r.Db.Builder.Insert("templates").Columns(
-		"template_name",
-	).Values(req.TemplateName).Suffix(
-		"RETURNING id, template_name, created_at, updated_at")
+		"id, template_name, description, body, body_schema, status, owner_sub",
+	).Suffix("RETURNING " + templateColumns)
 
-	err := query.RunWith(r.Db.Db).Scan(
-		&res.Id, &res.TemplateName,
-		&CreatedAt, &UpdatedAt,
-	)
+	ids := make([]string, len(reqs))
+	for i, req := range reqs {
+		ids[i] = uuid.New().String()
+		query = query.Values(
+			ids[i], req.TemplateName, req.Description,
+			jsonOrEmpty(req.Body), jsonOrNull(req.BodySchema), req.Status, req.OwnerSub,
+		)
+	}
+
+	rows, err := query.RunWith(tx).QueryContext(ctx)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "TemplateCreate: query.RunWith(r.Db.Db).Scan()")
+		return nil, err
+	}
+
+	created := map[string]*models.TemplateResponse{}
+	for rows.Next() {
+		temp, err := scanTemplate(rows, false)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		created[temp.Id] = temp
 	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]*models.TemplateResponse, len(reqs))
+	for i, req := range reqs {
+		res[i] = created[ids[i]]
+
+		if res[i].Tags, err = r.setTemplateTags(ctx, tx, res[i].Id, req.Tags); err != nil {
+			return nil, err
+		}
 
-	res.CreatedAt = CreatedAt.Format(time.RFC1123)
-	res.UpdatedAt = UpdatedAt.Format(time.RFC1123)
+		if err := r.insertTemplateRevision(ctx, tx, res[i]); err != nil {
+			return nil, err
+		}
+
+		err = r.afterChange(ctx, tx, change{
+			action:       audit.ActionTemplateCreate,
+			event:        events.TemplateCreated,
+			resourceType: "template",
+			resourceId:   res[i].Id,
+			after:        res[i],
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return res, nil
 }
 
 func (r *postgresRepo) TemplateGet(ctx context.Context, req *models.TemplateGetReq) (*models.TemplateResponse, error) {
-	query := r.Db.Builder.Select("id, template_name, created_at, updated_at").
+	query := r.Db.Builder.Select(templateColumns, templateTagsColumn).
 		From("templates")
 
 	if req.Id != "" {
@@ -41,33 +111,30 @@ func (r *postgresRepo) TemplateGet(ctx context.Context, req *models.TemplateGetR
 		return &models.TemplateResponse{}, fmt.Errorf("at least one filter should be exists")
 	}
 
-	res := &models.TemplateResponse{}
-	err := query.RunWith(r.Db.Db).QueryRow().Scan(
-		&res.Id, &res.TemplateName,
-		&CreatedAt, &UpdatedAt,
-	)
+	res, err := scanTemplate(query.RunWith(r.Db.Db).QueryRowContext(ctx), true)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "TemplateGet:query.RunWith(r.Db.Db).QueryRow()")
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateGet:query.RunWith(r.Db.Db).QueryRow()")
 	}
 
-	res.CreatedAt = CreatedAt.Format(time.RFC1123)
-	res.UpdatedAt = UpdatedAt.Format(time.RFC1123)
-
 	return res, nil
 }
 
 func (r *postgresRepo) TemplateFind(ctx context.Context, req *models.TemplateFindReq) (*models.TemplateFindResponse, error) {
 	var (
-		res            = &models.TemplateFindResponse{}
-		whereCondition = squirrel.And{}
-		orderBy        = []string{}
+		res                  = &models.TemplateFindResponse{}
+		orderBy              = []string{}
+		page                 *keysetPage
+		keys                 []cursor.Cursor
+		createdAt, updatedAt time.Time
 	)
 
-	if strings.TrimSpace(req.Search) != "" {
-		whereCondition = append(whereCondition, squirrel.ILike{"template_name": req.Search + "%"})
-	}
+	whereCondition, search := templateFindWhere(req)
+	fullText := search != ""
 
-	if req.OrderByCreatedAt != 0 {
+	orderBy = req.Filter.OrderBy("id")
+	if len(orderBy) == 0 && fullText {
+		orderBy = append(orderBy, "rank DESC", "id ASC")
+	} else if len(orderBy) == 0 && req.OrderByCreatedAt != 0 {
 		if req.OrderByCreatedAt > 0 {
 			orderBy = append(orderBy, "created_at DESC")
 		} else {
@@ -75,77 +142,312 @@ func (r *postgresRepo) TemplateFind(ctx context.Context, req *models.TemplateFin
 		}
 	}
 
-	countQuery := r.Db.Builder.Select("count(1) as count").From("templates").Where("deleted_at is null").Where(whereCondition)
-	err := countQuery.RunWith(r.Db.Db).QueryRow().Scan(&res.Count)
-	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "TemplateFind: countQuery.RunWith(r.Db.Db).QueryRow().Scan()")
+	if req.WithCount {
+		res.Count = new(int)
+		countQuery := r.Db.Builder.Select("count(1) as count").From("templates").Where("deleted_at is null").Where(whereCondition)
+		err := countQuery.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(res.Count)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "TemplateFind: countQuery.RunWith(r.Db.Db).QueryRow().Scan()")
+		}
 	}
 
-	query := r.Db.Builder.Select("id, template_name, created_at, updated_at").
-		From("templates").Where("deleted_at is null").Where(whereCondition)
+	query := r.Db.Builder.Select(
+		"id, template_name, description, status, owner_sub, created_at, updated_at, version", templateTagsColumn,
+	).From("templates").Where("deleted_at is null").Where(whereCondition)
 
-	if len(orderBy) > 0 {
-		query = query.OrderBy(strings.Join(orderBy, ", "))
+	if fullText {
+		query = query.Column(squirrel.Expr(
+			"ts_rank(search_vector, websearch_to_tsquery('english', ?)) + word_similarity(?, template_name) AS rank",
+			search, search,
+		)).Column(squirrel.Expr(
+			"ts_headline('english', template_name, websearch_to_tsquery('english', ?), 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS snippet",
+			search,
+		))
 	}
 
-	query = query.Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+	if req.Keyset {
+		var err error
+		page, err = r.newKeysetPage(req.Cursor, req.Limit, req.Filter, whereCondition)
+		if err != nil {
+			return res, err
+		}
+		query = page.apply(query)
+	} else {
+		if len(orderBy) > 0 {
+			query = query.OrderBy(strings.Join(orderBy, ", "))
+		}
 
-	rows, err := query.RunWith(r.Db.Db).Query()
+		query = query.Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+	}
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "TemplateFind: query.RunWith(r.Db.Db).Query()")
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateFind: query.RunWith(r.Db.Db).Query()")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		temp := &models.TemplateResponse{}
-		err := rows.Scan(
-			&temp.Id, &temp.TemplateName,
-			&CreatedAt, &UpdatedAt,
-		)
+		dest := []any{
+			&temp.Id, &temp.TemplateName, &temp.Description, &temp.Status, &temp.OwnerSub,
+			&createdAt, &updatedAt, &temp.Version, pq.Array(&temp.Tags),
+		}
+		if fullText {
+			dest = append(dest, &temp.Rank, &temp.Snippet)
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
-			return res, HandleDatabaseError(err, r.Log, "TemplateFind: rows.Scan()")
+			return res, HandleDatabaseError(ctx, err, r.Log, "TemplateFind: rows.Scan()")
 		}
 
-		temp.CreatedAt = CreatedAt.Format(time.RFC1123)
-		temp.UpdatedAt = UpdatedAt.Format(time.RFC1123)
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		temp.UpdatedAt = updatedAt.Format(time.RFC1123)
 		res.Templates = append(res.Templates, temp)
+		keys = append(keys, cursor.Cursor{CreatedAt: createdAt, Id: temp.Id})
+	}
+
+	if page != nil {
+		var n int
+		n, res.NextCursor, res.PrevCursor = page.cursors(keys)
+		res.Templates = res.Templates[:n]
+		if page.backward() {
+			reverse(res.Templates)
+		}
 	}
 
 	return res, nil
 }
 
+// TemplateExport calls fn with every template TemplateFind would find, one
+// at a time without paging. It stops at the first error fn returns.
+func (r *postgresRepo) TemplateExport(ctx context.Context, req *models.TemplateFindReq, fn func(*models.TemplateResponse) error) error {
+	whereCondition, _ := templateFindWhere(req)
+
+	orderBy := req.Filter.OrderBy("id")
+	if len(orderBy) == 0 {
+		orderBy = []string{"created_at", "id"}
+	}
+
+	query := r.Db.Builder.Select(templateColumns, templateTagsColumn).
+		From("templates").Where("deleted_at is null").Where(whereCondition).
+		OrderBy(orderBy...)
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return HandleDatabaseError(ctx, err, r.Log, "TemplateExport: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp, err := scanTemplate(rows, true)
+		if err != nil {
+			return HandleDatabaseError(ctx, err, r.Log, "TemplateExport: rows.Scan()")
+		}
+		if err := fn(temp); err != nil {
+			return err
+		}
+	}
+
+	return HandleDatabaseError(ctx, rows.Err(), r.Log, "TemplateExport: rows.Err()")
+}
+
+// templateFindWhere returns the conditions of req and, when it is a
+// full-text search, the trimmed search.
+func templateFindWhere(req *models.TemplateFindReq) (whereCondition squirrel.And, fullTextSearch string) {
+	search := strings.TrimSpace(req.Search)
+	if search != "" && req.SearchMode == models.SearchModeFullText {
+		// words are matched by the tsvector, typos fall back to trigram similarity
+		whereCondition = append(whereCondition, squirrel.Or{
+			squirrel.Expr("search_vector @@ websearch_to_tsquery('english', ?)", search),
+			squirrel.Expr("? <% template_name", search),
+		})
+		fullTextSearch = search
+	} else if search != "" {
+		whereCondition = append(whereCondition, squirrel.ILike{"template_name": req.Search + "%"})
+	}
+	if len(req.Tags) > 0 {
+		whereCondition = append(whereCondition, squirrel.Expr(`id IN (
+			SELECT tt.template_id FROM template_tags tt JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tg.name = ANY(?) GROUP BY tt.template_id HAVING count(1) = ?)`,
+			pq.Array(req.Tags), len(req.Tags),
+		))
+	}
+	if req.VisibleTo != nil {
+		whereCondition = append(whereCondition, squirrel.Or{
+			squirrel.Eq{"owner_sub": []string{"", *req.VisibleTo}},
+			squirrel.Expr("id IN (SELECT template_id FROM template_grants WHERE user_id = NULLIF(?, '')::uuid)", *req.VisibleTo),
+		})
+	}
+	whereCondition = append(whereCondition, req.Filter.Where()...)
+
+	return whereCondition, fullTextSearch
+}
+
 func (r *postgresRepo) TemplateUpdate(ctx context.Context, req *models.TemplateUpdateReq) (*models.TemplateResponse, error) {
-	var (
-		mp             = make(map[string]interface{})
-		whereCondition = squirrel.And{squirrel.Eq{"id": req.Id}}
-	)
+	var res *models.TemplateResponse
+	err := r.withTx(ctx, func(tx *sql.Tx) (err error) {
+		res, err = r.templateUpdate(ctx, tx, req, audit.ActionTemplateUpdate)
+		return err
+	})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateUpdate: query.RunWith(tx).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+// templateUpdate updates the template guarded by req.Version and stores
+// the result as a new revision.
+func (r *postgresRepo) templateUpdate(ctx context.Context, tx *sql.Tx, req *models.TemplateUpdateReq, action string) (*models.TemplateResponse, error) {
+	mp := make(map[string]interface{})
 	mp["template_name"] = req.TemplateName
+	mp["description"] = req.Description
+	mp["body"] = jsonOrEmpty(req.Body)
+	mp["body_schema"] = jsonOrNull(req.BodySchema)
+	mp["status"] = req.Status
 	mp["updated_at"] = time.Now()
+	mp["version"] = squirrel.Expr("version + 1")
 
 	query := r.Db.Builder.Update("templates").SetMap(mp).
-		Where(whereCondition).
-		Suffix("RETURNING id, template_name, created_at, updated_at")
+		Where(squirrel.Eq{"id": req.Id}).
+		Suffix("RETURNING " + templateColumns)
 
-	res := &models.TemplateResponse{}
-	err := query.RunWith(r.Db.Db).QueryRow().Scan(
-		&res.Id, &res.TemplateName,
-		&CreatedAt, &UpdatedAt,
-	)
+	before, err := r.templateForUpdate(ctx, tx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version > 0 && before.Version != req.Version {
+		return nil, status.Error(codes.FailedPrecondition, "Template has been modified by someone else")
+	}
 
+	res, err := scanTemplate(query.RunWith(tx).QueryRowContext(ctx), false)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "TemplateUpdate: query.RunWith(r.Db.Db).QueryRow().Scan()")
+		return nil, err
 	}
-	res.CreatedAt = CreatedAt.Format(time.RFC1123)
-	res.UpdatedAt = UpdatedAt.Format(time.RFC1123)
 
-	return res, nil
+	if res.Tags, err = r.setTemplateTags(ctx, tx, res.Id, req.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertTemplateRevision(ctx, tx, res); err != nil {
+		return nil, err
+	}
+
+	return res, r.afterChange(ctx, tx, change{
+		action:       action,
+		event:        events.TemplateUpdated,
+		resourceType: "template",
+		resourceId:   res.Id,
+		before:       before,
+		after:        res,
+	})
 }
 
 func (r *postgresRepo) TemplateDelete(ctx context.Context, req *models.TemplateDeleteReq) error {
-	whereCondition := squirrel.And{squirrel.Eq{"id": req.Id}}
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		return r.templateDelete(ctx, tx, req.Id, 0)
+	})
+	return HandleDatabaseError(ctx, err, r.Log, "TemplateDelete: query.RunWith(tx).Exec()")
+}
+
+// templateDelete deletes the template guarded by version, 0 deletes any version.
+func (r *postgresRepo) templateDelete(ctx context.Context, tx *sql.Tx, id string, version int) error {
+	before, err := r.templateForUpdate(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if version > 0 && before.Version != version {
+		return status.Error(codes.FailedPrecondition, "Template has been modified by someone else")
+	}
+
+	if _, err := r.Db.Builder.Delete("templates").Where(squirrel.Eq{"id": id}).RunWith(tx).ExecContext(ctx); err != nil {
+		return err
+	}
+
+	return r.afterChange(ctx, tx, change{
+		action:       audit.ActionTemplateDelete,
+		event:        events.TemplateDeleted,
+		resourceType: "template",
+		resourceId:   id,
+		before:       before,
+	})
+}
 
-	query := r.Db.Builder.Delete("templates").Where(whereCondition)
+// templateForUpdate reads the template and locks it until tx ends.
+func (r *postgresRepo) templateForUpdate(ctx context.Context, tx *sql.Tx, id string) (*models.TemplateResponse, error) {
+	query := r.Db.Builder.Select(templateColumns, templateTagsColumn).
+		From("templates").Where(squirrel.Eq{"id": id}).Suffix("FOR UPDATE")
 
-	_, err := query.RunWith(r.Db.Db).Exec()
-	return HandleDatabaseError(err, r.Log, "TemplateDelete: query.RunWith(r.Db.Db).Exec()")
+	return scanTemplate(query.RunWith(tx).QueryRowContext(ctx), true)
+}
+
+// setTemplateTags replaces the tags of the template, creating missing
+// ones, and returns them sorted.
+func (r *postgresRepo) setTemplateTags(ctx context.Context, tx *sql.Tx, id string, tags []string) ([]string, error) {
+	_, err := r.Db.Builder.Delete("template_tags").Where(squirrel.Eq{"template_id": id}).RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := append([]string{}, tags...)
+	sort.Strings(res)
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, pq.Array(res))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO template_tags (template_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`, id, pq.Array(res))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// scanTemplate scans templateColumns, followed by templateTagsColumn when withTags.
+func scanTemplate(row squirrel.RowScanner, withTags bool) (*models.TemplateResponse, error) {
+	var (
+		res                  = &models.TemplateResponse{Tags: []string{}}
+		bodySchema           []byte
+		createdAt, updatedAt time.Time
+	)
+
+	dest := []any{
+		&res.Id, &res.TemplateName, &res.Description, &res.Body, &bodySchema,
+		&res.Status, &res.OwnerSub, &createdAt, &updatedAt, &res.Version,
+	}
+	if withTags {
+		dest = append(dest, pq.Array(&res.Tags))
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return res, err
+	}
+
+	res.BodySchema = bodySchema
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+	return res, nil
+}
+
+// jsonOrEmpty returns v, or an empty object when v is not given.
+func jsonOrEmpty(v json.RawMessage) []byte {
+	if len(v) == 0 || string(v) == "null" {
+		return []byte("{}")
+	}
+	return v
+}
+
+// jsonOrNull returns v, or nil which is stored as NULL when v is not given.
+func jsonOrNull(v json.RawMessage) []byte {
+	if len(v) == 0 || string(v) == "null" {
+		return nil
+	}
+	return v
 }
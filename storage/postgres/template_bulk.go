@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+)
+
+// errBulkAborted rolls back an atomic bulk after one of its operations failed.
+var errBulkAborted = errors.New("bulk aborted")
+
+// TemplateBulk applies the operations in one transaction and returns a
+// result for each of them. Creates are inserted with one statement, the
+// other operations run in the order they are given. Every operation runs
+// in a savepoint, so in best effort mode a failed one does not undo the
+// others, in atomic mode the first failure rolls back all of them.
+func (r *postgresRepo) TemplateBulk(ctx context.Context, req *models.TemplateBulkReq) ([]*models.TemplateBulkResult, error) {
+	var results []*models.TemplateBulkResult
+	err := r.withTx(ctx, func(tx *sql.Tx) (err error) {
+		results, err = r.templateBulk(ctx, tx, req)
+		return err
+	})
+
+	if errors.Is(err, errBulkAborted) {
+		for _, res := range results {
+			if res.Status == models.BulkStatusSucceeded {
+				res.Status = models.BulkStatusAborted
+				res.Template = nil
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, HandleDatabaseError(ctx, err, r.Log, "TemplateBulk: r.withTx()")
+	}
+
+	return results, nil
+}
+
+// templateBulk applies the operations in tx. It returns errBulkAborted,
+// with the results, when an operation failed in atomic mode.
+func (r *postgresRepo) templateBulk(ctx context.Context, tx *sql.Tx, req *models.TemplateBulkReq) ([]*models.TemplateBulkResult, error) {
+	var (
+		atomic  = req.Mode != models.BulkModeBestEffort
+		results = make([]*models.TemplateBulkResult, len(req.Operations))
+		creates []*models.TemplateBulkOperation
+		others  []*models.TemplateBulkOperation
+	)
+
+	for i, op := range req.Operations {
+		results[i] = &models.TemplateBulkResult{Index: op.Index, Op: op.Op, Id: op.Id, Status: models.BulkStatusAborted}
+		if op.Op == models.BulkOpCreate {
+			creates = append(creates, op)
+		} else {
+			others = append(others, op)
+		}
+	}
+	byIndex := make(map[int]*models.TemplateBulkResult, len(results))
+	for _, res := range results {
+		byIndex[res.Index] = res
+	}
+
+	// run applies op and records the outcome, it returns errBulkAborted
+	// when op failed in atomic mode.
+	run := func(tx *sql.Tx, op *models.TemplateBulkOperation) error {
+		res := byIndex[op.Index]
+		err := savepoint(ctx, tx, func() (err error) {
+			res.Template, err = r.templateBulkApply(ctx, tx, req.OwnerSub, op)
+			return err
+		})
+		if err != nil {
+			res.Status = models.BulkStatusFailed
+			res.Err = HandleDatabaseError(ctx, err, r.Log, "TemplateBulk: r.templateBulkApply()")
+			res.Template = nil
+			if atomic {
+				return errBulkAborted
+			}
+			return nil
+		}
+
+		res.Status = models.BulkStatusSucceeded
+		if res.Template != nil {
+			res.Id = res.Template.Id
+		}
+		return nil
+	}
+
+	if len(creates) > 0 {
+		var created []*models.TemplateResponse
+		err := savepoint(ctx, tx, func() (err error) {
+			reqs := make([]*models.TemplateCreateReq, len(creates))
+			for i, op := range creates {
+				reqs[i] = &models.TemplateCreateReq{TemplateContent: op.TemplateContent, OwnerSub: req.OwnerSub}
+			}
+			created, err = r.templateCreate(ctx, tx, reqs)
+			return err
+		})
+
+		if err == nil {
+			for i, op := range creates {
+				res := byIndex[op.Index]
+				res.Status = models.BulkStatusSucceeded
+				res.Id = created[i].Id
+				res.Template = created[i]
+			}
+		} else {
+			// find out which of them failed
+			for _, op := range creates {
+				if err := run(tx, op); err != nil {
+					return results, err
+				}
+			}
+		}
+	}
+
+	for _, op := range others {
+		if err := run(tx, op); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func (r *postgresRepo) templateBulkApply(ctx context.Context, tx *sql.Tx, ownerSub string, op *models.TemplateBulkOperation) (*models.TemplateResponse, error) {
+	switch op.Op {
+	case models.BulkOpCreate:
+		res, err := r.templateCreate(ctx, tx, []*models.TemplateCreateReq{{TemplateContent: op.TemplateContent, OwnerSub: ownerSub}})
+		if err != nil {
+			return nil, err
+		}
+		return res[0], nil
+	case models.BulkOpUpdate:
+		return r.templateUpdate(ctx, tx, &models.TemplateUpdateReq{
+			Id:              op.Id,
+			TemplateContent: op.TemplateContent,
+			Version:         op.Version,
+		}, audit.ActionTemplateUpdate)
+	case models.BulkOpDelete:
+		return nil, r.templateDelete(ctx, tx, op.Id, op.Version)
+	}
+	return nil, errors.New("unknown bulk operation " + op.Op)
+}
+
+// savepoint runs fn so that when it fails only what fn did is rolled back
+// and tx can go on.
+func savepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT bulk_operation"); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT bulk_operation"); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT bulk_operation")
+	return err
+}
@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+	"github.com/google/uuid"
+)
+
+// TemplateAccess returns the owner of the template and the role the user
+// has on it.
+func (r *postgresRepo) TemplateAccess(ctx context.Context, req *models.TemplateAccessReq) (*models.TemplateAccessResponse, error) {
+	query := r.Db.Builder.Select("t.owner_sub, COALESCE(g.role, '')").
+		From("templates t").
+		LeftJoin("template_grants g ON g.template_id = t.id AND g.user_id = NULLIF(?, '')::uuid", req.UserId).
+		Where(squirrel.Eq{"t.id": req.TemplateId})
+
+	res := &models.TemplateAccessResponse{}
+	err := query.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(&res.OwnerSub, &res.Role)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateAccess: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	if req.UserId != "" && res.OwnerSub == req.UserId {
+		res.Role = models.TemplateRoleOwner
+	}
+
+	return res, nil
+}
+
+// TemplateGrant gives the user a role on the template, replacing the one
+// the user had.
+func (r *postgresRepo) TemplateGrant(ctx context.Context, req *models.TemplateGrantReq) (*models.TemplateCollaboratorResponse, error) {
+	var createdAt, updatedAt time.Time
+
+	query := r.Db.Builder.Insert("template_grants").Columns(
+		"template_id, user_id, role, granted_by",
+	).Values(req.TemplateId, req.UserId, req.Role, audit.ActorFrom(ctx).Sub).Suffix(`
+		ON CONFLICT (template_id, user_id) DO UPDATE SET role = EXCLUDED.role, granted_by = EXCLUDED.granted_by, updated_at = NOW()
+		RETURNING user_id, role, granted_by, created_at, updated_at`)
+
+	res := &models.TemplateCollaboratorResponse{}
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := query.RunWith(tx).QueryRowContext(ctx).Scan(
+			&res.UserId, &res.Role, &res.GrantedBy, &createdAt, &updatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = r.Db.Builder.Select("user_name").From("users").Where(squirrel.Eq{"id": req.UserId}).
+			RunWith(tx).QueryRowContext(ctx).Scan(&res.UserName)
+		if err != nil {
+			return err
+		}
+
+		res.CreatedAt = createdAt.Format(time.RFC1123)
+		res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+		return r.afterChange(ctx, tx, change{
+			action:       audit.ActionTemplateGrant,
+			resourceType: "template",
+			resourceId:   req.TemplateId,
+			after:        map[string]string{"user_id": res.UserId, "role": res.Role},
+		})
+	})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateGrant: query.RunWith(tx).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) TemplateGrantFind(ctx context.Context, req *models.TemplateGrantFindReq) (*models.TemplateCollaboratorFindResponse, error) {
+	var createdAt, updatedAt time.Time
+
+	query := r.Db.Builder.Select("g.user_id, u.user_name, g.role, g.granted_by, g.created_at, g.updated_at").
+		From("template_grants g").Join("users u ON u.id = g.user_id").
+		Where(squirrel.Eq{"g.template_id": req.TemplateId}).
+		OrderBy("g.created_at", "g.user_id")
+
+	res := &models.TemplateCollaboratorFindResponse{}
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateGrantFind: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := &models.TemplateCollaboratorResponse{}
+		err := rows.Scan(
+			&temp.UserId, &temp.UserName, &temp.Role, &temp.GrantedBy,
+			&createdAt, &updatedAt,
+		)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "TemplateGrantFind: rows.Scan()")
+		}
+
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		temp.UpdatedAt = updatedAt.Format(time.RFC1123)
+		res.Collaborators = append(res.Collaborators, temp)
+	}
+	res.Count = len(res.Collaborators)
+
+	return res, nil
+}
+
+func (r *postgresRepo) TemplateGrantDelete(ctx context.Context, req *models.TemplateGrantDeleteReq) error {
+	query := r.Db.Builder.Delete("template_grants").
+		Where(squirrel.Eq{"template_id": req.TemplateId, "user_id": req.UserId}).
+		Suffix("RETURNING role")
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var role string
+		if err := query.RunWith(tx).QueryRowContext(ctx).Scan(&role); err != nil {
+			return err
+		}
+
+		return r.afterChange(ctx, tx, change{
+			action:       audit.ActionTemplateRevoke,
+			resourceType: "template",
+			resourceId:   req.TemplateId,
+			before:       map[string]string{"user_id": req.UserId, "role": role},
+		})
+	})
+	return HandleDatabaseError(ctx, err, r.Log, "TemplateGrantDelete: query.RunWith(tx).QueryRow().Scan()")
+}
+
+func (r *postgresRepo) TemplateShareLinkCreate(ctx context.Context, req *models.TemplateShareLinkCreateReq) (*models.TemplateShareLinkResponse, error) {
+	query := r.Db.Builder.Insert("template_share_links").Columns(
+		"id, template_id, token_hash, created_by, expires_at",
+	).Values(
+		uuid.New().String(), req.TemplateId, req.TokenHash, audit.ActorFrom(ctx).Sub, req.ExpiresAt,
+	).Suffix("RETURNING id, created_by, expires_at, created_at")
+
+	var res *models.TemplateShareLinkResponse
+	err := r.withTx(ctx, func(tx *sql.Tx) (err error) {
+		res, err = scanTemplateShareLink(query.RunWith(tx).QueryRowContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		return r.afterChange(ctx, tx, change{
+			action:       audit.ActionTemplateShare,
+			resourceType: "template",
+			resourceId:   req.TemplateId,
+			after:        res,
+		})
+	})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateShareLinkCreate: query.RunWith(tx).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) TemplateShareLinkFind(ctx context.Context, req *models.TemplateShareLinkFindReq) (*models.TemplateShareLinkFindResponse, error) {
+	query := r.Db.Builder.Select("id, created_by, expires_at, created_at").
+		From("template_share_links").
+		Where(squirrel.Eq{"template_id": req.TemplateId}).
+		OrderBy("created_at DESC", "id")
+
+	res := &models.TemplateShareLinkFindResponse{}
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateShareLinkFind: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp, err := scanTemplateShareLink(rows)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "TemplateShareLinkFind: rows.Scan()")
+		}
+		res.Links = append(res.Links, temp)
+	}
+	res.Count = len(res.Links)
+
+	return res, nil
+}
+
+func (r *postgresRepo) TemplateShareLinkDelete(ctx context.Context, req *models.TemplateShareLinkDeleteReq) error {
+	query := r.Db.Builder.Delete("template_share_links").
+		Where(squirrel.Eq{"id": req.Id, "template_id": req.TemplateId}).
+		Suffix("RETURNING id, created_by, expires_at, created_at")
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := scanTemplateShareLink(query.RunWith(tx).QueryRowContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		return r.afterChange(ctx, tx, change{
+			action:       audit.ActionTemplateUnshare,
+			resourceType: "template",
+			resourceId:   req.TemplateId,
+			before:       before,
+		})
+	})
+	return HandleDatabaseError(ctx, err, r.Log, "TemplateShareLinkDelete: query.RunWith(tx).QueryRow().Scan()")
+}
+
+// TemplateSharedGet returns the template a share link which has not
+// expired points to.
+func (r *postgresRepo) TemplateSharedGet(ctx context.Context, req *models.TemplateSharedGetReq) (*models.TemplateResponse, error) {
+	query := r.Db.Builder.Select(templateColumns, templateTagsColumn).
+		From("templates").
+		Where(`id = (SELECT template_id FROM template_share_links
+			WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > NOW()))`, req.TokenHash)
+
+	res, err := scanTemplate(query.RunWith(r.Db.Db).QueryRowContext(ctx), true)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateSharedGet: query.RunWith(r.Db.Db).QueryRow()")
+	}
+
+	return res, nil
+}
+
+func scanTemplateShareLink(row squirrel.RowScanner) (*models.TemplateShareLinkResponse, error) {
+	var (
+		res       = &models.TemplateShareLinkResponse{}
+		expiresAt sql.NullTime
+		createdAt time.Time
+	)
+
+	err := row.Scan(&res.Id, &res.CreatedBy, &expiresAt, &createdAt)
+	if err != nil {
+		return res, err
+	}
+
+	if expiresAt.Valid {
+		res.ExpiresAt = expiresAt.Time.Format(time.RFC1123)
+	}
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+
+	return res, nil
+}
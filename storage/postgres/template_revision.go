@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+)
+
+// insertTemplateRevision saves template as the revision of its version.
+func (r *postgresRepo) insertTemplateRevision(ctx context.Context, tx squirrel.BaseRunner, template *models.TemplateResponse) error {
+	snapshot, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Db.Builder.Insert("template_revisions").Columns(
+		"template_id, revision, actor_sub, snapshot",
+	).Values(
+		template.Id, template.Version, audit.ActorFrom(ctx).Sub, snapshot,
+	).RunWith(tx).ExecContext(ctx)
+
+	return err
+}
+
+func (r *postgresRepo) TemplateRevisionFind(ctx context.Context, req *models.TemplateRevisionFindReq) (*models.TemplateRevisionFindResponse, error) {
+	var (
+		res            = &models.TemplateRevisionFindResponse{}
+		whereCondition = squirrel.Eq{"template_id": req.TemplateId}
+	)
+
+	countQuery := r.Db.Builder.Select("count(1) as count").From("template_revisions").Where(whereCondition)
+	err := countQuery.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(&res.Count)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRevisionFind: countQuery.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	query := r.Db.Builder.Select("template_id, revision, actor_sub, snapshot, created_at").
+		From("template_revisions").Where(whereCondition).
+		OrderBy("revision DESC").
+		Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRevisionFind: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp, err := scanTemplateRevision(rows)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRevisionFind: rows.Scan()")
+		}
+		res.Revisions = append(res.Revisions, temp)
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) TemplateRevisionGet(ctx context.Context, req *models.TemplateRevisionGetReq) (*models.TemplateRevisionResponse, error) {
+	res, err := r.templateRevisionGet(ctx, r.Db.Db, req)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRevisionGet: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+// TemplateRevisionDiff returns the fields changed from one revision to another.
+func (r *postgresRepo) TemplateRevisionDiff(ctx context.Context, req *models.TemplateRevisionDiffReq) (*models.TemplateRevisionDiffResponse, error) {
+	res := &models.TemplateRevisionDiffResponse{
+		TemplateId: req.TemplateId,
+		From:       req.From,
+		To:         req.To,
+	}
+
+	from, err := r.templateRevisionGet(ctx, r.Db.Db, &models.TemplateRevisionGetReq{TemplateId: req.TemplateId, Revision: req.From})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRevisionDiff: r.templateRevisionGet(from)")
+	}
+
+	to, err := r.templateRevisionGet(ctx, r.Db.Db, &models.TemplateRevisionGetReq{TemplateId: req.TemplateId, Revision: req.To})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRevisionDiff: r.templateRevisionGet(to)")
+	}
+
+	res.Diff = audit.Diff(from.Snapshot, to.Snapshot)
+
+	return res, nil
+}
+
+// TemplateRestore saves the content of an old revision as the new head, so
+// the history is kept and the restore itself is a revision.
+func (r *postgresRepo) TemplateRestore(ctx context.Context, req *models.TemplateRestoreReq) (*models.TemplateResponse, error) {
+	var res *models.TemplateResponse
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		revision, err := r.templateRevisionGet(ctx, tx, &models.TemplateRevisionGetReq{
+			TemplateId: req.TemplateId,
+			Revision:   req.Revision,
+		})
+		if err != nil {
+			return err
+		}
+
+		snapshot := revision.Snapshot
+		if snapshot.Status == "" {
+			// saved before templates had a status
+			snapshot.Status = models.TemplateStatusDraft
+		}
+
+		res, err = r.templateUpdate(ctx, tx, &models.TemplateUpdateReq{
+			Id: req.TemplateId,
+			TemplateContent: models.TemplateContent{
+				TemplateName: snapshot.TemplateName,
+				Description:  snapshot.Description,
+				Body:         snapshot.Body,
+				BodySchema:   snapshot.BodySchema,
+				Tags:         snapshot.Tags,
+				Status:       snapshot.Status,
+			},
+			Version: req.Version,
+		}, audit.ActionTemplateRestore)
+		return err
+	})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "TemplateRestore: r.templateUpdate()")
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) templateRevisionGet(ctx context.Context, runner squirrel.BaseRunner, req *models.TemplateRevisionGetReq) (*models.TemplateRevisionResponse, error) {
+	query := r.Db.Builder.Select("template_id, revision, actor_sub, snapshot, created_at").
+		From("template_revisions").
+		Where(squirrel.Eq{"template_id": req.TemplateId, "revision": req.Revision})
+
+	return scanTemplateRevision(query.RunWith(runner).QueryRowContext(ctx))
+}
+
+func scanTemplateRevision(row squirrel.RowScanner) (*models.TemplateRevisionResponse, error) {
+	var (
+		res       = &models.TemplateRevisionResponse{}
+		snapshot  []byte
+		createdAt time.Time
+	)
+
+	err := row.Scan(&res.TemplateId, &res.Revision, &res.ActorSub, &snapshot, &createdAt)
+	if err != nil {
+		return res, err
+	}
+
+	if err := json.Unmarshal(snapshot, &res.Snapshot); err != nil {
+		return res, err
+	}
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+
+	return res, nil
+}
@@ -2,36 +2,66 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/audit"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/cursor"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (r *postgresRepo) UserCreate(ctx context.Context, req *models.UserCreateReq) (*models.UserResponse, error) {
+	var res *models.UserResponse
+	err := r.withTx(ctx, func(tx *sql.Tx) (err error) {
+		res, err = r.userCreate(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "(r *UserRepo) Create()")
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) userCreate(ctx context.Context, tx *sql.Tx, req *models.UserCreateReq) (*models.UserResponse, error) {
+	var createdAt, updatedAt time.Time
+
 	res := &models.UserResponse{}
 	query := r.Db.Builder.Insert("users").Columns(
 		"id, user_name, email, hashed_password, refresh_token",
 	).Values(req.Id, req.UserName, req.Email, req.Password, req.RefreshToken).Suffix(
-		"RETURNING id, user_name, email, hashed_password, refresh_token, created_at, updated_at")
+		"RETURNING id, user_name, email, hashed_password, refresh_token, created_at, updated_at, version, role")
 
-	err := query.RunWith(r.Db.Db).Scan(
+	err := query.RunWith(tx).QueryRowContext(ctx).Scan(
 		&res.Id, &res.UserName,
 		&res.Email, &res.Password,
-		&res.RefreshToken, &CreatedAt, &UpdatedAt,
+		&res.RefreshToken, &createdAt, &updatedAt,
+		&res.Version, &res.Role,
 	)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "(r *UserRepo) Create()")
+		return res, err
 	}
-	res.CreatedAt = CreatedAt.Format(time.RFC1123)
-	res.UpdatedAt = UpdatedAt.Format(time.RFC1123)
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
 
-	return res, nil
+	return res, r.afterChange(ctx, tx, change{
+		action:       audit.ActionUserRegister,
+		event:        events.UserRegistered,
+		resourceType: "user",
+		resourceId:   res.Id,
+		after:        res,
+	})
 }
 
 func (r *postgresRepo) UserGet(ctx context.Context, req *models.UserGetReq) (*models.UserResponse, error) {
-	query := r.Db.Builder.Select("id, user_name, email, hashed_password, refresh_token, created_at, updated_at").
+	var createdAt, updatedAt time.Time
+
+	query := r.Db.Builder.Select("id, user_name, email, hashed_password, refresh_token, created_at, updated_at, version, role").
 		From("users")
 
 	if req.Id != "" {
@@ -45,39 +75,63 @@ func (r *postgresRepo) UserGet(ctx context.Context, req *models.UserGetReq) (*mo
 	}
 
 	res := &models.UserResponse{}
-	err := query.RunWith(r.Db.Db).QueryRow().Scan(
+	err := query.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(
 		&res.Id, &res.UserName,
 		&res.Email, &res.Password,
-		&res.RefreshToken, &CreatedAt, &UpdatedAt,
+		&res.RefreshToken, &createdAt, &updatedAt,
+		&res.Version, &res.Role,
 	)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "(r *UserRepo) Get()")
+		return res, HandleDatabaseError(ctx, err, r.Log, "(r *UserRepo) Get()")
 	}
 
-	res.CreatedAt = CreatedAt.Format(time.RFC1123)
-	res.UpdatedAt = UpdatedAt.Format(time.RFC1123)
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
 
 	return res, nil
 }
 
 func (r *postgresRepo) UserFind(ctx context.Context, req *models.UserFindReq) (*models.UserFindResponse, error) {
 	var (
-		res = &models.UserFindResponse{}
+		res                  = &models.UserFindResponse{}
+		whereCondition       = req.Filter.Where()
+		orderBy              = req.Filter.OrderBy("id")
+		page                 *keysetPage
+		keys                 []cursor.Cursor
+		createdAt, updatedAt time.Time
 	)
 
-	countQuery := r.Db.Builder.Select("count(1) as count").From("users").Where("deleted_at is null")
-	err := countQuery.RunWith(r.Db.Db).QueryRow().Scan(&res.Count)
-	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "(r *models.UserUserRepo) FindList()")
+	if len(orderBy) == 0 {
+		orderBy = []string{"id"}
+	}
+
+	if req.WithCount {
+		res.Count = new(int)
+		countQuery := r.Db.Builder.Select("count(1) as count").From("users").Where("deleted_at is null").Where(whereCondition)
+		err := countQuery.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(res.Count)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "(r *models.UserUserRepo) FindList()")
 
+		}
 	}
 
-	query := r.Db.Builder.Select("id, user_name, created_at, updated_at").
-		From("users").Where("deleted_at is null").OrderBy("id").Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+	query := r.Db.Builder.Select("id, user_name, created_at, updated_at, version").
+		From("users").Where("deleted_at is null").Where(whereCondition)
+
+	if req.Keyset {
+		var err error
+		page, err = r.newKeysetPage(req.Cursor, req.Limit, req.Filter, whereCondition)
+		if err != nil {
+			return res, err
+		}
+		query = page.apply(query)
+	} else {
+		query = query.OrderBy(orderBy...).Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+	}
 
-	rows, err := query.RunWith(r.Db.Db).Query()
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "(r *models.UserUserRepo) FindList()")
+		return res, HandleDatabaseError(ctx, err, r.Log, "(r *models.UserUserRepo) FindList()")
 	}
 	defer rows.Close()
 
@@ -85,39 +139,131 @@ func (r *postgresRepo) UserFind(ctx context.Context, req *models.UserFindReq) (*
 		temp := &models.UserResponse{}
 		err := rows.Scan(
 			&temp.Id, &temp.UserName,
-			&CreatedAt, &UpdatedAt,
+			&createdAt, &updatedAt, &temp.Version,
 		)
 		if err != nil {
-			return res, HandleDatabaseError(err, r.Log, "(r *models.UserUserRepo) FindList()")
+			return res, HandleDatabaseError(ctx, err, r.Log, "(r *models.UserUserRepo) FindList()")
 		}
 
-		temp.CreatedAt = CreatedAt.Format(time.RFC1123)
-		temp.UpdatedAt = UpdatedAt.Format(time.RFC1123)
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		temp.UpdatedAt = updatedAt.Format(time.RFC1123)
 		res.Users = append(res.Users, temp)
+		keys = append(keys, cursor.Cursor{CreatedAt: createdAt, Id: temp.Id})
+	}
+
+	if page != nil {
+		var n int
+		n, res.NextCursor, res.PrevCursor = page.cursors(keys)
+		res.Users = res.Users[:n]
+		if page.backward() {
+			reverse(res.Users)
+		}
 	}
 
 	return res, nil
 }
 
+// UserExport calls fn with every user UserFind would find, one at a time
+// without paging. It stops at the first error fn returns.
+func (r *postgresRepo) UserExport(ctx context.Context, req *models.UserFindReq, fn func(*models.UserResponse) error) error {
+	var createdAt, updatedAt time.Time
+
+	orderBy := req.Filter.OrderBy("id")
+	if len(orderBy) == 0 {
+		orderBy = []string{"id"}
+	}
+
+	query := r.Db.Builder.Select("id, user_name, email, role, created_at, updated_at, version").
+		From("users").Where("deleted_at is null").Where(req.Filter.Where()).
+		OrderBy(orderBy...)
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return HandleDatabaseError(ctx, err, r.Log, "UserExport: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := &models.UserResponse{}
+		err := rows.Scan(
+			&temp.Id, &temp.UserName, &temp.Email, &temp.Role,
+			&createdAt, &updatedAt, &temp.Version,
+		)
+		if err != nil {
+			return HandleDatabaseError(ctx, err, r.Log, "UserExport: rows.Scan()")
+		}
+
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		temp.UpdatedAt = updatedAt.Format(time.RFC1123)
+		if err := fn(temp); err != nil {
+			return err
+		}
+	}
+
+	return HandleDatabaseError(ctx, rows.Err(), r.Log, "UserExport: rows.Err()")
+}
+
 func (r *postgresRepo) UserUpdate(ctx context.Context, req *models.UserUpdateReq) (*models.UserResponse, error) {
 	mp := make(map[string]interface{})
 	mp["user_name"] = req.UserName
 	mp["updated_at"] = time.Now()
+	mp["version"] = squirrel.Expr("version + 1")
+
+	return r.userUpdate(ctx, req.Id, req.Version, mp, audit.ActionUserUpdate, events.UserUpdated)
+}
+
+func (r *postgresRepo) UserPasswordUpdate(ctx context.Context, req *models.UserPasswordUpdateReq) error {
+	mp := make(map[string]interface{})
+	mp["hashed_password"] = req.HashedPassword
+	mp["updated_at"] = time.Now()
+	mp["version"] = squirrel.Expr("version + 1")
+
+	_, err := r.userUpdate(ctx, req.Id, 0, mp, audit.ActionUserPasswordReset, events.UserPasswordReset)
+	return err
+}
+
+// userUpdate sets mp on the user guarded by version, 0 updates any version.
+func (r *postgresRepo) userUpdate(ctx context.Context, id string, version int, mp map[string]interface{}, action, event string) (*models.UserResponse, error) {
+	var createdAt, updatedAt time.Time
+
 	query := r.Db.Builder.Update("users").SetMap(mp).
-		Where(squirrel.Eq{"id": req.Id}).
-		Suffix("RETURNING id, user_name, email, hashed_password, refresh_token, created_at, updated_at")
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING id, user_name, email, hashed_password, refresh_token, created_at, updated_at, version, role")
 
 	res := &models.UserResponse{}
-	err := query.RunWith(r.Db.Db).QueryRow().Scan(
-		&res.Id, &res.UserName,
-		&res.Email, &res.Password,
-		&res.RefreshToken, &CreatedAt, &UpdatedAt,
-	)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.userForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if version > 0 && before.Version != version {
+			return status.Error(codes.FailedPrecondition, "User has been modified by someone else")
+		}
+
+		err = query.RunWith(tx).QueryRowContext(ctx).Scan(
+			&res.Id, &res.UserName,
+			&res.Email, &res.Password,
+			&res.RefreshToken, &createdAt, &updatedAt,
+			&res.Version, &res.Role,
+		)
+		if err != nil {
+			return err
+		}
+		res.CreatedAt = createdAt.Format(time.RFC1123)
+		res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+		return r.afterChange(ctx, tx, change{
+			action:       action,
+			event:        event,
+			resourceType: "user",
+			resourceId:   res.Id,
+			before:       before,
+			after:        res,
+		})
+	})
 	if err != nil {
-		return res, HandleDatabaseError(err, r.Log, "UserUpdate:query.RunWith(r.Db.Db).QueryRow()")
+		return res, HandleDatabaseError(ctx, err, r.Log, "UserUpdate:query.RunWith(tx).QueryRow()")
 	}
-	res.CreatedAt = CreatedAt.Format(time.RFC1123)
-	res.UpdatedAt = UpdatedAt.Format(time.RFC1123)
 
 	return res, nil
 }
@@ -125,6 +271,46 @@ func (r *postgresRepo) UserUpdate(ctx context.Context, req *models.UserUpdateReq
 func (r *postgresRepo) UserDelete(ctx context.Context, req *models.UserDeleteReq) error {
 	query := r.Db.Builder.Delete("users").Where(squirrel.Eq{"id": req.Id})
 
-	_, err := query.RunWith(r.Db.Db).Exec()
-	return HandleDatabaseError(err, r.Log, "UserDelete: query.RunWith(r.Db.Db).Exec()")
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.userForUpdate(ctx, tx, req.Id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := query.RunWith(tx).ExecContext(ctx); err != nil {
+			return err
+		}
+
+		return r.afterChange(ctx, tx, change{
+			action:       audit.ActionUserDelete,
+			event:        events.UserDeleted,
+			resourceType: "user",
+			resourceId:   req.Id,
+			before:       before,
+		})
+	})
+	return HandleDatabaseError(ctx, err, r.Log, "UserDelete: query.RunWith(tx).Exec()")
+}
+
+// userForUpdate reads the user and locks it until tx ends.
+func (r *postgresRepo) userForUpdate(ctx context.Context, tx *sql.Tx, id string) (*models.UserResponse, error) {
+	var createdAt, updatedAt time.Time
+
+	query := r.Db.Builder.Select("id, user_name, email, hashed_password, refresh_token, created_at, updated_at, version, role").
+		From("users").Where(squirrel.Eq{"id": id}).Suffix("FOR UPDATE")
+
+	res := &models.UserResponse{}
+	err := query.RunWith(tx).QueryRowContext(ctx).Scan(
+		&res.Id, &res.UserName,
+		&res.Email, &res.Password,
+		&res.RefreshToken, &createdAt, &updatedAt,
+		&res.Version, &res.Role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+	return res, nil
 }
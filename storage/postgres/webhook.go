@@ -0,0 +1,373 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, url, event_types, active, created_at, updated_at"
+
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_response_code, d.last_error, d.created_at, d.updated_at`
+
+func (r *postgresRepo) WebhookCreate(ctx context.Context, req *models.WebhookCreateReq) (*models.WebhookResponse, error) {
+	var createdAt, updatedAt time.Time
+
+	res := &models.WebhookResponse{}
+	query := r.Db.Builder.Insert("webhooks").Columns(
+		"id, owner_sub, url, secret, event_types",
+	).Values(uuid.New().String(), req.OwnerSub, req.Url, req.Secret, pq.Array(req.EventTypes)).Suffix(
+		"RETURNING " + webhookColumns)
+
+	err := query.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(
+		&res.Id, &res.Url, pq.Array(&res.EventTypes), &res.Active,
+		&createdAt, &updatedAt,
+	)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookCreate: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	res.Secret = req.Secret
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+	return res, nil
+}
+
+func (r *postgresRepo) WebhookGet(ctx context.Context, req *models.WebhookGetReq) (*models.WebhookResponse, error) {
+	var createdAt, updatedAt time.Time
+
+	query := r.Db.Builder.Select(webhookColumns).From("webhooks").
+		Where(squirrel.Eq{"id": req.Id, "owner_sub": req.OwnerSub})
+
+	res := &models.WebhookResponse{}
+	err := query.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(
+		&res.Id, &res.Url, pq.Array(&res.EventTypes), &res.Active,
+		&createdAt, &updatedAt,
+	)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookGet: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+	return res, nil
+}
+
+func (r *postgresRepo) WebhookFind(ctx context.Context, req *models.WebhookFindReq) (*models.WebhookFindResponse, error) {
+	var (
+		res                  = &models.WebhookFindResponse{}
+		whereCondition       = squirrel.Eq{"owner_sub": req.OwnerSub}
+		createdAt, updatedAt time.Time
+	)
+
+	countQuery := r.Db.Builder.Select("count(1) as count").From("webhooks").Where(whereCondition)
+	err := countQuery.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(&res.Count)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookFind: countQuery.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	query := r.Db.Builder.Select(webhookColumns).From("webhooks").Where(whereCondition).
+		OrderBy("created_at DESC", "id").
+		Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookFind: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := &models.WebhookResponse{}
+		err := rows.Scan(
+			&temp.Id, &temp.Url, pq.Array(&temp.EventTypes), &temp.Active,
+			&createdAt, &updatedAt,
+		)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "WebhookFind: rows.Scan()")
+		}
+
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		temp.UpdatedAt = updatedAt.Format(time.RFC1123)
+		res.Webhooks = append(res.Webhooks, temp)
+	}
+
+	return res, nil
+}
+
+func (r *postgresRepo) WebhookUpdate(ctx context.Context, req *models.WebhookUpdateReq) (*models.WebhookResponse, error) {
+	var createdAt, updatedAt time.Time
+
+	mp := make(map[string]interface{})
+	mp["url"] = req.Url
+	mp["event_types"] = pq.Array(req.EventTypes)
+	mp["active"] = req.Active
+	mp["updated_at"] = time.Now()
+	if req.Secret != "" {
+		mp["secret"] = req.Secret
+	}
+
+	query := r.Db.Builder.Update("webhooks").SetMap(mp).
+		Where(squirrel.Eq{"id": req.Id, "owner_sub": req.OwnerSub}).
+		Suffix("RETURNING " + webhookColumns)
+
+	res := &models.WebhookResponse{}
+	err := query.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(
+		&res.Id, &res.Url, pq.Array(&res.EventTypes), &res.Active,
+		&createdAt, &updatedAt,
+	)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookUpdate: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	res.Secret = req.Secret
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+	return res, nil
+}
+
+func (r *postgresRepo) WebhookDelete(ctx context.Context, req *models.WebhookDeleteReq) error {
+	query := r.Db.Builder.Delete("webhooks").Where(squirrel.Eq{"id": req.Id, "owner_sub": req.OwnerSub})
+
+	result, err := query.RunWith(r.Db.Db).ExecContext(ctx)
+	if err == nil {
+		if n, _ := result.RowsAffected(); n == 0 {
+			err = sql.ErrNoRows
+		}
+	}
+	return HandleDatabaseError(ctx, err, r.Log, "WebhookDelete: query.RunWith(r.Db.Db).Exec()")
+}
+
+func (r *postgresRepo) WebhookDeliveryFind(ctx context.Context, req *models.WebhookDeliveryFindReq) (*models.WebhookDeliveryFindResponse, error) {
+	var (
+		res            = &models.WebhookDeliveryFindResponse{}
+		whereCondition = squirrel.And{
+			squirrel.Eq{"d.webhook_id": req.WebhookId},
+			squirrel.Eq{"w.owner_sub": req.OwnerSub},
+		}
+	)
+	if req.Status != "" {
+		whereCondition = append(whereCondition, squirrel.Eq{"d.status": req.Status})
+	}
+
+	countQuery := r.Db.Builder.Select("count(1) as count").
+		From("webhook_deliveries d").Join("webhooks w ON w.id = d.webhook_id").
+		Where(whereCondition)
+	err := countQuery.RunWith(r.Db.Db).QueryRowContext(ctx).Scan(&res.Count)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryFind: countQuery.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	query := r.Db.Builder.Select(webhookDeliveryColumns).
+		From("webhook_deliveries d").Join("webhooks w ON w.id = d.webhook_id").
+		Where(whereCondition).
+		OrderBy("d.id DESC").
+		Limit(uint64(req.Limit)).Offset(uint64((req.Page - 1) * req.Limit))
+
+	rows, err := query.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryFind: query.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryFind: rows.Scan()")
+		}
+		res.Deliveries = append(res.Deliveries, temp)
+	}
+
+	return res, nil
+}
+
+// WebhookDeliveryGet returns the delivery with the log of its attempts.
+func (r *postgresRepo) WebhookDeliveryGet(ctx context.Context, req *models.WebhookDeliveryGetReq) (*models.WebhookDeliveryResponse, error) {
+	var createdAt time.Time
+
+	query := r.Db.Builder.Select(webhookDeliveryColumns).
+		From("webhook_deliveries d").Join("webhooks w ON w.id = d.webhook_id").
+		Where(squirrel.Eq{"d.id": req.Id, "w.owner_sub": req.OwnerSub})
+
+	res, err := scanWebhookDelivery(query.RunWith(r.Db.Db).QueryRowContext(ctx))
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryGet: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	logQuery := r.Db.Builder.Select("response_code, error, duration_ms, created_at").
+		From("webhook_delivery_attempts").
+		Where(squirrel.Eq{"delivery_id": req.Id}).
+		OrderBy("id")
+
+	rows, err := logQuery.RunWith(r.Db.Db).QueryContext(ctx)
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryGet: logQuery.RunWith(r.Db.Db).Query()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := &models.WebhookDeliveryAttempt{}
+		err := rows.Scan(&temp.ResponseCode, &temp.Error, &temp.DurationMs, &createdAt)
+		if err != nil {
+			return res, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryGet: rows.Scan()")
+		}
+
+		temp.CreatedAt = createdAt.Format(time.RFC1123)
+		res.Log = append(res.Log, temp)
+	}
+
+	return res, nil
+}
+
+// WebhookRedeliver schedules the delivery to be sent again right away with
+// a fresh number of attempts, whatever its status is.
+func (r *postgresRepo) WebhookRedeliver(ctx context.Context, req *models.WebhookDeliveryGetReq) (*models.WebhookDeliveryResponse, error) {
+	query := r.Db.Builder.Update("webhook_deliveries d").
+		Set("status", models.WebhookDeliveryPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", squirrel.Expr("NOW()")).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		From("webhooks w").
+		Where("w.id = d.webhook_id").
+		Where(squirrel.Eq{"d.id": req.Id, "w.owner_sub": req.OwnerSub}).
+		Suffix("RETURNING " + webhookDeliveryColumns)
+
+	res, err := scanWebhookDelivery(query.RunWith(r.Db.Db).QueryRowContext(ctx))
+	if err != nil {
+		return res, HandleDatabaseError(ctx, err, r.Log, "WebhookRedeliver: query.RunWith(r.Db.Db).QueryRow().Scan()")
+	}
+
+	return res, nil
+}
+
+// WebhookEnqueue creates the deliveries of an event for the webhooks whose
+// owners can read the template: its owner, the users it is shared with, or
+// everyone when it has no owner. It can be called again for the same event,
+// deliveries which already exist are kept. The parameters are cast, as the
+// same one is used for columns and values of different types.
+func (r *postgresRepo) WebhookEnqueue(ctx context.Context, req *models.WebhookEnqueueReq) error {
+	_, err := r.Db.Db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT w.id, $1, $2::text, $3 FROM webhooks w
+		WHERE w.active AND $2::text = ANY(w.event_types) AND (
+			$4::text = '' OR w.owner_sub = $4::text OR EXISTS (
+				SELECT 1 FROM template_grants g
+				WHERE g.template_id = NULLIF($5::text, '')::uuid AND g.user_id::text = w.owner_sub
+			)
+		)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		req.EventId, req.EventType, []byte(req.Payload), req.OwnerSub, req.TemplateId,
+	)
+
+	return HandleDatabaseError(ctx, err, r.Log, "WebhookEnqueue")
+}
+
+// WebhookDeliveryClaim takes due deliveries of active webhooks for sending.
+// They are not claimed again until req.Lease ends, so a delivery whose
+// result was never recorded is retried by any worker.
+func (r *postgresRepo) WebhookDeliveryClaim(ctx context.Context, req *models.WebhookDeliveryClaimReq) ([]*models.WebhookDelivery, error) {
+	rows, err := r.Db.Db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $1 * INTERVAL '1 millisecond'
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, w.url, w.secret, d.event_type, d.payload, d.attempts`,
+		req.Lease.Milliseconds(), req.Limit,
+	)
+	if err != nil {
+		return nil, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryClaim: r.Db.Db.Query()")
+	}
+	defer rows.Close()
+
+	res := []*models.WebhookDelivery{}
+	for rows.Next() {
+		temp := &models.WebhookDelivery{}
+		err := rows.Scan(
+			&temp.Id, &temp.WebhookId, &temp.Url, &temp.Secret,
+			&temp.EventType, &temp.Payload, &temp.Attempts,
+		)
+		if err != nil {
+			return nil, HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryClaim: rows.Scan()")
+		}
+		res = append(res, temp)
+	}
+
+	return res, HandleDatabaseError(ctx, rows.Err(), r.Log, "WebhookDeliveryClaim: rows.Err()")
+}
+
+// WebhookDeliveryRecord logs an attempt of a claimed delivery and schedules
+// the next one, or marks the delivery dead after req.MaxAttempts failures.
+func (r *postgresRepo) WebhookDeliveryRecord(ctx context.Context, req *models.WebhookDeliveryRecordReq) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := r.Db.Builder.Insert("webhook_delivery_attempts").Columns(
+			"delivery_id, response_code, error, duration_ms",
+		).Values(req.Id, req.ResponseCode, req.Error, req.Duration.Milliseconds()).RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		var attempts int
+		err = r.Db.Builder.Update("webhook_deliveries").
+			Set("attempts", squirrel.Expr("attempts + 1")).
+			Set("last_response_code", req.ResponseCode).
+			Set("last_error", req.Error).
+			Set("updated_at", time.Now()).
+			Where(squirrel.Eq{"id": req.Id}).
+			Suffix("RETURNING attempts").
+			RunWith(tx).QueryRowContext(ctx).Scan(&attempts)
+		if err != nil {
+			return err
+		}
+
+		update := r.Db.Builder.Update("webhook_deliveries").Where(squirrel.Eq{"id": req.Id})
+		switch {
+		case req.Succeeded:
+			update = update.Set("status", models.WebhookDeliverySucceeded)
+		case attempts >= req.MaxAttempts:
+			update = update.Set("status", models.WebhookDeliveryDead)
+		default:
+			update = update.Set("next_attempt_at", time.Now().Add(retryBackoff(attempts-1, req.MaxBackoff)))
+		}
+
+		_, err = update.RunWith(tx).ExecContext(ctx)
+		return err
+	})
+
+	return HandleDatabaseError(ctx, err, r.Log, "WebhookDeliveryRecord")
+}
+
+func scanWebhookDelivery(row squirrel.RowScanner) (*models.WebhookDeliveryResponse, error) {
+	var (
+		res                  = &models.WebhookDeliveryResponse{}
+		nextAttemptAt        time.Time
+		createdAt, updatedAt time.Time
+	)
+
+	err := row.Scan(
+		&res.Id, &res.WebhookId, &res.EventId, &res.EventType, &res.Payload,
+		&res.Status, &res.Attempts, &nextAttemptAt,
+		&res.LastResponseCode, &res.LastError, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return res, err
+	}
+
+	res.NextAttemptAt = nextAttemptAt.Format(time.RFC1123)
+	res.CreatedAt = createdAt.Format(time.RFC1123)
+	res.UpdatedAt = updatedAt.Format(time.RFC1123)
+
+	return res, nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/models"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/events"
+	"github.com/google/uuid"
+)
+
+func TestWebhookEnqueue(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+
+	owner, grantee, stranger := createTestUser(t, r), createTestUser(t, r), createTestUser(t, r)
+	webhookOf := map[string]string{}
+	for _, sub := range []string{owner, grantee, stranger} {
+		res, err := r.WebhookCreate(ctx, &models.WebhookCreateReq{
+			Url:        "https://example.com/hook",
+			Secret:     "secret",
+			EventTypes: []string{events.TemplateUpdated},
+			OwnerSub:   sub,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		webhookOf[sub] = res.Id
+		t.Cleanup(func() { r.Db.Db.Exec("DELETE FROM webhooks WHERE id = $1", res.Id) })
+	}
+
+	owned := createTestTemplate(t, r, owner)
+	if _, err := r.Db.Db.Exec("INSERT INTO template_grants (template_id, user_id, role) VALUES ($1, $2, 'viewer')", owned, grantee); err != nil {
+		t.Fatal(err)
+	}
+	legacy := createTestTemplate(t, r, "")
+
+	tests := []struct {
+		name       string
+		templateId string
+		ownerSub   string
+		want       []string
+	}{
+		{"owned", owned, owner, []string{webhookOf[owner], webhookOf[grantee]}},
+		{"legacy", legacy, "", []string{webhookOf[owner], webhookOf[grantee], webhookOf[stranger]}},
+		{"deleted", uuid.New().String(), owner, []string{webhookOf[owner]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventId := rand.Int63()
+			t.Cleanup(func() { r.Db.Db.Exec("DELETE FROM webhook_deliveries WHERE event_id = $1", eventId) })
+
+			err := r.WebhookEnqueue(ctx, &models.WebhookEnqueueReq{
+				EventId:    eventId,
+				EventType:  events.TemplateUpdated,
+				Payload:    []byte("{}"),
+				TemplateId: tt.templateId,
+				OwnerSub:   tt.ownerSub,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// webhooks of other tests may exist, only the ones created here count
+			var got []string
+			rows, err := r.Db.Db.Query("SELECT webhook_id FROM webhook_deliveries WHERE event_id = $1", eventId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var id string
+				if err := rows.Scan(&id); err != nil {
+					t.Fatal(err)
+				}
+				for _, webhookId := range webhookOf {
+					if id == webhookId {
+						got = append(got, id)
+					}
+				}
+			}
+
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if len(got) != len(tt.want) {
+				t.Fatalf("deliveries for %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("deliveries for %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func createTestUser(t *testing.T, r *postgresRepo) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := r.Db.Db.Exec("INSERT INTO users (id, user_name, email, hashed_password) VALUES ($1, $1, $1, '')", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Db.Db.Exec("DELETE FROM users WHERE id = $1", id) })
+	return id
+}
+
+func createTestTemplate(t *testing.T, r *postgresRepo, ownerSub string) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := r.Db.Db.Exec("INSERT INTO templates (id, owner_sub) VALUES ($1, $2)", id, ownerSub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Db.Db.Exec("DELETE FROM templates WHERE id = $1", id) })
+	return id
+}
@@ -0,0 +1,79 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrLocked is returned by Acquire when someone else holds the lock.
+	ErrLocked = errors.New("redisrepo: lock is held")
+	// ErrLockLost is returned by Release when the lock expired and may have
+	// been acquired by someone else in the meantime.
+	ErrLockLost = errors.New("redisrepo: lock was lost")
+)
+
+// fenceKey is the counter fencing tokens are taken from. It is shared by
+// all locks, so there is no key per lock to clean up.
+const fenceKey = "fence"
+
+// Locker hands out locks which expire, so a crashed holder does not keep
+// them forever.
+//
+// A holder paused for longer than the ttl, e.g. by GC, can still believe
+// it holds an expired lock. Writes made under the lock should therefore
+// carry Lock.Token, and the resource should reject tokens smaller than the
+// largest one it has seen.
+type Locker struct {
+	store InMemoryStorageI
+}
+
+// NewLocker returns a Locker keeping locks under lock: in store.
+func NewLocker(store InMemoryStorageI) *Locker {
+	return &Locker{store: Namespaced(store, "lock")}
+}
+
+type Lock struct {
+	Name string
+	// Token is larger than the tokens of all earlier acquisitions.
+	Token int64
+
+	locker *Locker
+}
+
+// Acquire takes the lock called name for ttl or returns ErrLocked.
+func (l *Locker) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, errors.New("redisrepo: lock ttl must be positive")
+	}
+
+	token, err := l.store.Incr(ctx, fenceKey, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	acquired, err := l.store.SetNX(ctx, "held:"+name, strconv.FormatInt(token, 10), ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrLocked
+	}
+
+	return &Lock{Name: name, Token: token, locker: l}, nil
+}
+
+// Release gives the lock up. It returns ErrLockLost when the lock had
+// expired, the critical section may then have run concurrently.
+func (lock *Lock) Release(ctx context.Context) error {
+	released, err := lock.locker.store.CompareAndDelete(ctx, "held:"+lock.Name, strconv.FormatInt(lock.Token, 10))
+	if err != nil {
+		return err
+	}
+	if !released {
+		return ErrLockLost
+	}
+	return nil
+}
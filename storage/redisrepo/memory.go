@@ -0,0 +1,322 @@
+package redisrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often writes drop expired keys which were not read.
+const sweepInterval = time.Minute
+
+// MemoryRepo keeps keys in the memory of the process, for tests and
+// deployments running a single instance.
+type MemoryRepo struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time // zero when the key does not expire
+}
+
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{
+		items: map[string]memoryItem{},
+		now:   time.Now,
+	}
+}
+
+func (r *MemoryRepo) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (r *MemoryRepo) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return errors.New("redisrepo: negative ttl")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = r.now().Add(ttl)
+	}
+	r.set(key, item)
+	return nil
+}
+
+func (r *MemoryRepo) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		return false, errors.New("redisrepo: negative ttl")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.get(key); ok {
+		return false, nil
+	}
+
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = r.now().Add(ttl)
+	}
+	r.set(key, item)
+	return true, nil
+}
+
+func (r *MemoryRepo) SetJSON(ctx context.Context, key string, value any, ttl time.Duration) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.Set(ctx, key, string(body), ttl)
+}
+
+func (r *MemoryRepo) GetString(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.get(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (r *MemoryRepo) GetJSON(ctx context.Context, key string, dest any) error {
+	value, err := r.GetString(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(value), dest)
+}
+
+func (r *MemoryRepo) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.get(key)
+	return ok, nil
+}
+
+func (r *MemoryRepo) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, errors.New("redisrepo: negative ttl")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.get(key)
+	if !ok {
+		item = memoryItem{value: "0"}
+		if ttl > 0 {
+			item.expiresAt = r.now().Add(ttl)
+		}
+	}
+
+	n, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil || n == math.MaxInt64 {
+		return 0, errors.New("ERR value is not an integer or out of range")
+	}
+	n++
+
+	item.value = strconv.FormatInt(n, 10)
+	r.set(key, item)
+	return n, nil
+}
+
+func (r *MemoryRepo) Del(ctx context.Context, keys ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := r.get(key); ok {
+			delete(r.items, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *MemoryRepo) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item, ok := r.get(key); !ok || item.value != value {
+		return false, nil
+	}
+	delete(r.items, key)
+	return true, nil
+}
+
+// Scan returns the keys matching the Redis glob pattern when it is called,
+// sorted.
+func (r *MemoryRepo) Scan(ctx context.Context, pattern string) KeyIterator {
+	if err := ctx.Err(); err != nil {
+		return &sliceIterator{err: err}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []string
+	for key := range r.items {
+		if _, ok := r.get(key); ok && matchGlob(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return &sliceIterator{keys: keys}
+}
+
+// get returns the item of key, dropping it when it has expired.
+func (r *MemoryRepo) get(key string) (memoryItem, bool) {
+	item, ok := r.items[key]
+	if ok && r.expired(item) {
+		delete(r.items, key)
+		return item, false
+	}
+	return item, ok
+}
+
+func (r *MemoryRepo) set(key string, item memoryItem) {
+	r.items[key] = item
+
+	if now := r.now(); now.Sub(r.lastSweep) > sweepInterval {
+		r.lastSweep = now
+		for key, item := range r.items {
+			if r.expired(item) {
+				delete(r.items, key)
+			}
+		}
+	}
+}
+
+func (r *MemoryRepo) expired(item memoryItem) bool {
+	return !item.expiresAt.IsZero() && !r.now().Before(item.expiresAt)
+}
+
+// matchGlob tells whether s matches the Redis glob pattern: * matches any
+// sequence, ? any character, [abc], [^abc] and [a-z] a character of the
+// set and \ escapes the next character.
+func matchGlob(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for len(p) > 1 && p[1] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if matchGlob(string(p[1:]), string(str[i:])) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			end, ok := matchClass(p, str[0])
+			if !ok {
+				return false
+			}
+			p = p[end:]
+			str = str[1:]
+			continue
+		case '\\':
+			if len(p) > 1 {
+				p = p[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || p[0] != str[0] {
+				return false
+			}
+		}
+		p = p[1:]
+		str = str[1:]
+	}
+
+	return len(str) == 0
+}
+
+// matchClass matches c against the [...] set p starts with and returns
+// where the set ends in p.
+func matchClass(p []rune, c rune) (int, bool) {
+	i := 1
+	negate := i < len(p) && p[i] == '^'
+	if negate {
+		i++
+	}
+
+	matched := false
+	for ; i < len(p) && p[i] != ']'; i++ {
+		switch {
+		case p[i] == '\\' && i+1 < len(p):
+			i++
+			matched = matched || p[i] == c
+		case i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']':
+			lo, hi := p[i], p[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || lo <= c && c <= hi
+			i += 2
+		default:
+			matched = matched || p[i] == c
+		}
+	}
+	if i < len(p) {
+		i++ // past ]
+	}
+
+	return i, matched != negate
+}
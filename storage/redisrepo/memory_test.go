@@ -0,0 +1,12 @@
+package redisrepo_test
+
+import (
+	"testing"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo/redistest"
+)
+
+func TestMemoryRepo(t *testing.T) {
+	redistest.TestStore(t, redisrepo.NewMemoryRepo())
+}
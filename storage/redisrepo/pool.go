@@ -0,0 +1,51 @@
+package redisrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/gomodule/redigo/redis"
+)
+
+// NewPool creates the Redis pool shared by the api and background workers.
+// Connections idle for longer than cfg.RedisTestOnBorrow are checked
+// before they are used, so a restarted Redis does not fail the first
+// requests. When cfg.RedisMaxActive connections are in use, callers wait
+// for one to be returned until their context is done.
+func NewPool(cfg config.Config) *redis.Pool {
+	dialTimeout := time.Duration(cfg.RedisDialTimeout) * time.Second
+	testOnBorrow := time.Duration(cfg.RedisTestOnBorrow) * time.Second
+
+	return &redis.Pool{
+		MaxIdle:     cfg.RedisMaxIdle,
+		MaxActive:   cfg.RedisMaxActive,
+		Wait:        cfg.RedisMaxActive > 0,
+		IdleTimeout: time.Duration(cfg.RedisIdleTimeout) * time.Second,
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			return redis.DialContext(ctx, "tcp", cfg.RedisHost+":"+cfg.RedisPort,
+				redis.DialPassword(cfg.RedisPassword),
+				redis.DialDatabase(cfg.RedisDB),
+				redis.DialConnectTimeout(dialTimeout),
+				redis.DialUseTLS(cfg.RedisTLS),
+				redis.DialTLSSkipVerify(cfg.RedisTLSSkipVerify),
+			)
+		},
+		TestOnBorrow: func(conn redis.Conn, idleSince time.Time) error {
+			if time.Since(idleSince) < testOnBorrow {
+				return nil
+			}
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+}
+
+// Ping checks Redis can be reached through pool.
+func Ping(pool *redis.Pool) error {
+	conn := pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PING")
+	return err
+}
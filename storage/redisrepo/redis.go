@@ -1,6 +1,39 @@
 package redisrepo
 
-import "github.com/gomodule/redigo/redis"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/pkg/tracing"
+	"github.com/gomodule/redigo/redis"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo")
+
+// scanCount is how many keys SCAN is asked to look at per call.
+const scanCount = 500
+
+// incrScript increments a key and sets the expiry of keys it creates, in
+// one step so a key can not be left without one.
+var incrScript = redis.NewScript(1, `
+local n = redis.call('INCR', KEYS[1])
+if n == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// compareAndDeleteScript deletes a key only when it holds the value.
+var compareAndDeleteScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
 
 type RedisRepo struct {
 	Rds *redis.Pool
@@ -12,47 +45,170 @@ func NewRedisRepo(rds *redis.Pool) InMemoryStorageI {
 	}
 }
 
-func (r *RedisRepo) Exists(key string) (interface{}, error) {
-	conn := r.Rds.Get()
+// do runs a command on a connection of the pool, giving up when ctx is done.
+func (r *RedisRepo) do(ctx context.Context, cmd string, args ...interface{}) (reply interface{}, err error) {
+	ctx, span := startSpan(ctx, cmd)
+	defer func() { tracing.End(span, err) }()
+
+	conn, err := r.Rds.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer conn.Close()
 
-	return conn.Do("EXISTS", key)
+	return redis.DoContext(conn, ctx, cmd, args...)
 }
 
-func (r *RedisRepo) Set(key, value string) error {
-	conn := r.Rds.Get()
+// eval runs script like do runs a command.
+func (r *RedisRepo) eval(ctx context.Context, script *redis.Script, keysAndArgs ...interface{}) (reply interface{}, err error) {
+	ctx, span := startSpan(ctx, "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	conn, err := r.Rds.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer conn.Close()
 
-	_, err := conn.Do("SET", key, value)
+	return script.DoContext(ctx, conn, keysAndArgs...)
+}
 
+// startSpan starts the span of a command. Keys and values are left out,
+// they may hold personal data.
+func startSpan(ctx context.Context, cmd string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "redis "+cmd,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation(cmd)),
+	)
+}
+
+func (r *RedisRepo) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
 	return err
 }
 
-func (r *RedisRepo) SetWithTTL(key, value string, seconds int) error {
-	conn := r.Rds.Get()
-	defer conn.Close()
+func (r *RedisRepo) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("redisrepo: negative ttl")
+	}
+
+	args := []interface{}{key, value}
+	if ttl > 0 {
+		args = append(args, "PX", milliseconds(ttl))
+	}
 
-	_, err := conn.Do("SETEX", key, seconds, value)
+	_, err := r.do(ctx, "SET", args...)
 	return err
 }
 
-func (r *RedisRepo) Get(key string) (interface{}, error) {
-	conn := r.Rds.Get()
-	defer conn.Close()
+func (r *RedisRepo) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		return false, errors.New("redisrepo: negative ttl")
+	}
+
+	args := []interface{}{key, value, "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", milliseconds(ttl))
+	}
 
-	return conn.Do("GET", key)
+	reply, err := r.do(ctx, "SET", args...)
+	return reply != nil, err
 }
 
-func (r *RedisRepo) Del(key string) (interface{}, error) {
-	conn := r.Rds.Get()
-	defer conn.Close()
+func (r *RedisRepo) SetJSON(ctx context.Context, key string, value any, ttl time.Duration) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.Set(ctx, key, string(body), ttl)
+}
 
-	return conn.Do("DEL", key)
+func (r *RedisRepo) GetString(ctx context.Context, key string) (string, error) {
+	value, err := redis.String(r.do(ctx, "GET", key))
+	if err == redis.ErrNil {
+		return "", ErrNotFound
+	}
+	return value, err
 }
 
-func (r *RedisRepo) Keys(pattern string) (interface{}, error) {
-	conn := r.Rds.Get()
-	defer conn.Close()
+func (r *RedisRepo) GetJSON(ctx context.Context, key string, dest any) error {
+	value, err := redis.Bytes(r.do(ctx, "GET", key))
+	if err == redis.ErrNil {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, dest)
+}
+
+func (r *RedisRepo) Exists(ctx context.Context, key string) (bool, error) {
+	return redis.Bool(r.do(ctx, "EXISTS", key))
+}
+
+func (r *RedisRepo) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if ttl < 0 {
+		return 0, errors.New("redisrepo: negative ttl")
+	}
+
+	return redis.Int64(r.eval(ctx, incrScript, key, milliseconds(ttl)))
+}
+
+func (r *RedisRepo) Del(ctx context.Context, keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return redis.Int(r.do(ctx, "DEL", redis.Args{}.AddFlat(keys)...))
+}
+
+func (r *RedisRepo) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	return redis.Bool(r.eval(ctx, compareAndDeleteScript, key, value))
+}
+
+func (r *RedisRepo) Scan(ctx context.Context, pattern string) KeyIterator {
+	return &redisIterator{r: r, ctx: ctx, pattern: pattern}
+}
+
+// redisIterator calls SCAN for the next batch when the current one is
+// used up, each call on a connection of its own.
+type redisIterator struct {
+	r       *RedisRepo
+	ctx     context.Context
+	pattern string
+	cursor  int64
+	started bool
+	batch   []string
+	key     string
+	err     error
+}
+
+func (it *redisIterator) Next() bool {
+	for len(it.batch) == 0 {
+		if it.err != nil || it.started && it.cursor == 0 {
+			return false
+		}
+		it.started = true
+
+		reply, err := redis.Values(it.r.do(it.ctx, "SCAN", it.cursor, "MATCH", it.pattern, "COUNT", scanCount))
+		if err == nil {
+			_, err = redis.Scan(reply, &it.cursor, &it.batch)
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.key, it.batch = it.batch[0], it.batch[1:]
+	return true
+}
+
+func (it *redisIterator) Key() string { return it.key }
+
+func (it *redisIterator) Err() error { return it.err }
 
-	return conn.Do("KEYS", pattern)
+// milliseconds rounds ttl up to whole milliseconds, so short ones do not
+// become 0.
+func milliseconds(ttl time.Duration) int64 {
+	return int64((ttl + time.Millisecond - 1) / time.Millisecond)
 }
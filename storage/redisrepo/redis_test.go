@@ -0,0 +1,21 @@
+package redisrepo_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo/redistest"
+)
+
+func TestRedisRepo(t *testing.T) {
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("REDIS_HOST is not set")
+	}
+
+	pool := redisrepo.NewPool(config.Load())
+	defer pool.Close()
+
+	redistest.TestStore(t, redisrepo.NewRedisRepo(pool))
+}
@@ -0,0 +1,404 @@
+// Package redistest checks that an implementation of
+// redisrepo.InMemoryStorageI behaves like Redis. Tests of an implementation
+// call TestStore with a fresh instance of it:
+//
+//	func TestMemoryRepo(t *testing.T) {
+//		redistest.TestStore(t, redisrepo.NewMemoryRepo())
+//	}
+//
+// Keys are created under a random prefix and deleted at the end, so a real
+// Redis can be used. TestStore waits a bit more than a second for keys to
+// expire.
+package redistest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/storage/redisrepo"
+	"github.com/google/uuid"
+)
+
+// TestStore runs the checks against s and reports everything which is
+// wrong with it to t.
+func TestStore(t testing.TB, s redisrepo.InMemoryStorageI) {
+	t.Helper()
+	c := &checker{t: t, s: s, ctx: context.Background(), prefix: "redistest:" + uuid.New().String() + ":"}
+	defer c.cleanup()
+
+	c.testPing()
+	c.testGetSet()
+	c.testJSON()
+	c.testExists()
+	c.testIncr()
+	c.testSetNX()
+	c.testCompareAndDelete()
+	c.testDel()
+	c.testScan()
+	c.testNamespaced()
+	c.testCanceled()
+	c.testTTL()
+	c.testConcurrency()
+}
+
+type checker struct {
+	t      testing.TB
+	s      redisrepo.InMemoryStorageI
+	ctx    context.Context
+	prefix string
+}
+
+func (c *checker) errorf(format string, args ...any) {
+	c.t.Helper()
+	c.t.Errorf(format, args...)
+}
+
+func (c *checker) key(name string) string {
+	return c.prefix + name
+}
+
+func (c *checker) cleanup() {
+	keys, err := c.scan(c.s, c.prefix+"*")
+	if err != nil {
+		return
+	}
+	_, _ = c.s.Del(c.ctx, keys...)
+}
+
+// scan returns the keys of s matching pattern, sorted.
+func (c *checker) scan(s redisrepo.InMemoryStorageI, pattern string) ([]string, error) {
+	keys := []string{}
+	it := s.Scan(c.ctx, pattern)
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	sort.Strings(keys)
+	return keys, it.Err()
+}
+
+func (c *checker) set(key, value string, ttl time.Duration) {
+	if err := c.s.Set(c.ctx, key, value, ttl); err != nil {
+		c.errorf("Set(%q, %q, %s): %v", key, value, ttl, err)
+	}
+}
+
+// expect checks key holds value, or does not exist when value is nil.
+func (c *checker) expect(key string, value *string) {
+	got, err := c.s.GetString(c.ctx, key)
+	if value == nil {
+		if err != redisrepo.ErrNotFound {
+			c.errorf("GetString(%q) = %q, %v, want ErrNotFound", key, got, err)
+		}
+		return
+	}
+
+	if err != nil || got != *value {
+		c.errorf("GetString(%q) = %q, %v, want %q", key, got, err, *value)
+	}
+}
+
+func (c *checker) expectExists(key string, want bool) {
+	got, err := c.s.Exists(c.ctx, key)
+	if err != nil || got != want {
+		c.errorf("Exists(%q) = %t, %v, want %t", key, got, err, want)
+	}
+}
+
+func (c *checker) testPing() {
+	if err := c.s.Ping(c.ctx); err != nil {
+		c.errorf("Ping: %v", err)
+	}
+}
+
+func (c *checker) testGetSet() {
+	key := c.key("get-set")
+	c.expect(key, nil)
+
+	for _, value := range []string{"first", "second", "", "ünïcode\x00binary"} {
+		c.set(key, value, 0)
+		c.expect(key, &value)
+	}
+
+	if err := c.s.Set(c.ctx, c.key("get-set:invalid"), "value", -time.Second); err == nil {
+		c.errorf("Set with a negative ttl succeeded, want an error")
+	}
+}
+
+func (c *checker) testJSON() {
+	type value struct {
+		Name  string   `json:"name"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+	}
+	key := c.key("json")
+	want := value{Name: "name", Count: 3, Tags: []string{"a", "b"}}
+
+	var got value
+	if err := c.s.GetJSON(c.ctx, key, &got); err != redisrepo.ErrNotFound {
+		c.errorf("GetJSON(%q) of a missing key: %v, want ErrNotFound", key, err)
+	}
+
+	if err := c.s.SetJSON(c.ctx, key, want, 0); err != nil {
+		c.errorf("SetJSON(%q): %v", key, err)
+	}
+	if err := c.s.GetJSON(c.ctx, key, &got); err != nil || fmt.Sprint(got) != fmt.Sprint(want) {
+		c.errorf("GetJSON(%q) = %+v, %v, want %+v", key, got, err, want)
+	}
+
+	c.set(key, "not json", 0)
+	if err := c.s.GetJSON(c.ctx, key, &got); err == nil || err == redisrepo.ErrNotFound {
+		c.errorf("GetJSON(%q) of invalid JSON: %v, want a decoding error", key, err)
+	}
+}
+
+func (c *checker) testExists() {
+	key := c.key("exists")
+	c.expectExists(key, false)
+
+	c.set(key, "", 0)
+	c.expectExists(key, true)
+}
+
+func (c *checker) testIncr() {
+	key := c.key("incr")
+	for want := int64(1); want <= 3; want++ {
+		if got, err := c.s.Incr(c.ctx, key, 0); err != nil || got != want {
+			c.errorf("Incr(%q) = %d, %v, want %d", key, got, err, want)
+		}
+	}
+	want := "3"
+	c.expect(key, &want)
+
+	c.set(key, "not a number", 0)
+	if _, err := c.s.Incr(c.ctx, key, 0); err == nil {
+		c.errorf("Incr(%q) of a string succeeded, want an error", key)
+	}
+}
+
+func (c *checker) testSetNX() {
+	key := c.key("set-nx")
+	for _, want := range []bool{true, false} {
+		if got, err := c.s.SetNX(c.ctx, key, "first", 0); err != nil || got != want {
+			c.errorf("SetNX(%q) = %t, %v, want %t", key, got, err, want)
+		}
+	}
+	want := "first"
+	c.expect(key, &want)
+}
+
+func (c *checker) testCompareAndDelete() {
+	key := c.key("compare-and-delete")
+	c.set(key, "value", 0)
+
+	for _, tt := range []struct {
+		value string
+		want  bool
+	}{{"other", false}, {"value", true}, {"value", false}} {
+		if got, err := c.s.CompareAndDelete(c.ctx, key, tt.value); err != nil || got != tt.want {
+			c.errorf("CompareAndDelete(%q, %q) = %t, %v, want %t", key, tt.value, got, err, tt.want)
+		}
+	}
+	c.expectExists(key, false)
+}
+
+func (c *checker) testDel() {
+	key, other := c.key("del"), c.key("del:other")
+	c.set(key, "value", 0)
+	c.set(other, "value", 0)
+
+	for _, want := range []int{2, 0} {
+		got, err := c.s.Del(c.ctx, key, other, c.key("del:missing"))
+		if err != nil || got != want {
+			c.errorf("Del(%q, %q) = %d, %v, want %d", key, other, got, err, want)
+		}
+	}
+	if got, err := c.s.Del(c.ctx); err != nil || got != 0 {
+		c.errorf("Del() = %d, %v, want 0", got, err)
+	}
+	c.expect(key, nil)
+	c.expectExists(key, false)
+}
+
+func (c *checker) testScan() {
+	for _, name := range []string{"scan:a1", "scan:a2", "scan:b1", "scan:a/b", "scan:[x]"} {
+		c.set(c.key(name), "value", 0)
+	}
+	// more keys than one SCAN call returns
+	for i := 0; i < 1200; i++ {
+		c.set(c.key(fmt.Sprintf("scan-many:%d", i)), "value", 0)
+	}
+
+	for pattern, want := range map[string][]string{
+		"scan:*":      {"scan:[x]", "scan:a/b", "scan:a1", "scan:a2", "scan:b1"},
+		"scan:a*":     {"scan:a/b", "scan:a1", "scan:a2"},
+		"scan:?1":     {"scan:a1", "scan:b1"},
+		"scan:[ab]1":  {"scan:a1", "scan:b1"},
+		"scan:[^a]1":  {"scan:b1"},
+		"scan:[a-b]2": {"scan:a2"},
+		`scan:\[x\]`:  {"scan:[x]"},
+		"scan:c*":     {},
+	} {
+		got, err := c.scan(c.s, c.key(pattern))
+		if err != nil {
+			c.errorf("Scan(%q): %v", c.key(pattern), err)
+			continue
+		}
+
+		for i := range want {
+			want[i] = c.key(want[i])
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			c.errorf("Scan(%q) = %q, want %q", c.key(pattern), got, want)
+		}
+	}
+
+	got, err := c.scan(c.s, c.key("scan-many:*"))
+	seen := map[string]bool{}
+	for _, key := range got {
+		seen[key] = true
+	}
+	if err != nil || len(seen) != 1200 {
+		c.errorf("Scan(%q) returned %d distinct keys, %v, want 1200", c.key("scan-many:*"), len(seen), err)
+	}
+}
+
+func (c *checker) testNamespaced() {
+	first := redisrepo.Namespaced(c.s, c.key("ns:first"))
+	second := redisrepo.Namespaced(c.s, c.key("ns:second"))
+
+	if err := first.Set(c.ctx, "key", "first", 0); err != nil {
+		c.errorf("Namespaced Set: %v", err)
+	}
+	if got, err := second.GetString(c.ctx, "key"); err != redisrepo.ErrNotFound {
+		c.errorf("GetString of another namespace = %q, %v, want ErrNotFound", got, err)
+	}
+	if got, err := first.GetString(c.ctx, "key"); err != nil || got != "first" {
+		c.errorf("Namespaced GetString = %q, %v, want %q", got, err, "first")
+	}
+	want := "first"
+	c.expect(c.key("ns:first:key"), &want)
+
+	if keys, err := c.scan(first, "*"); err != nil || fmt.Sprint(keys) != "[key]" {
+		c.errorf("Namespaced Scan = %q, %v, want [key]", keys, err)
+	}
+	if n, err := second.Del(c.ctx, "key"); err != nil || n != 0 {
+		c.errorf("Del of another namespace = %d, %v, want 0", n, err)
+	}
+	if n, err := first.Del(c.ctx, "key"); err != nil || n != 1 {
+		c.errorf("Namespaced Del = %d, %v, want 1", n, err)
+	}
+}
+
+func (c *checker) testCanceled() {
+	ctx, cancel := context.WithCancel(c.ctx)
+	cancel()
+	key := c.key("canceled")
+
+	if err := c.s.Ping(ctx); err == nil {
+		c.errorf("Ping with a canceled context succeeded, want an error")
+	}
+	if err := c.s.Set(ctx, key, "value", 0); err == nil {
+		c.errorf("Set with a canceled context succeeded, want an error")
+	}
+	if _, err := c.s.GetString(ctx, key); err == nil || err == redisrepo.ErrNotFound {
+		c.errorf("GetString with a canceled context: %v, want the context error", err)
+	}
+	it := c.s.Scan(ctx, c.key("*"))
+	for it.Next() {
+	}
+	if it.Err() == nil {
+		c.errorf("Scan with a canceled context succeeded, want an error")
+	}
+	c.expectExists(key, false)
+}
+
+func (c *checker) testTTL() {
+	expiring, persisted := c.key("ttl:expiring"), c.key("ttl:persisted")
+	value := "value"
+
+	c.set(expiring, value, time.Second)
+	c.expect(expiring, &value)
+	c.expectExists(expiring, true)
+
+	// setting again without a ttl removes the expiry
+	c.set(persisted, value, time.Second)
+	c.set(persisted, value, 0)
+
+	// SetNX does not set keys which exist, so their ttl stays
+	locked := c.key("ttl:locked")
+	if ok, err := c.s.SetNX(c.ctx, locked, value, time.Second); err != nil || !ok {
+		c.errorf("SetNX(%q) = %t, %v, want true", locked, ok, err)
+	}
+	if ok, err := c.s.SetNX(c.ctx, locked, value, 0); err != nil || ok {
+		c.errorf("SetNX(%q) of a set key = %t, %v, want false", locked, ok, err)
+	}
+
+	// Incr sets the ttl of keys it creates only
+	counter, counted := c.key("ttl:counter"), c.key("ttl:counted")
+	for i := 0; i < 2; i++ {
+		if _, err := c.s.Incr(c.ctx, counter, time.Second); err != nil {
+			c.errorf("Incr(%q): %v", counter, err)
+		}
+	}
+	c.set(counted, "1", 0)
+	if _, err := c.s.Incr(c.ctx, counted, time.Second); err != nil {
+		c.errorf("Incr(%q): %v", counted, err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	c.expect(expiring, nil)
+	c.expectExists(expiring, false)
+	c.expect(counter, nil)
+	c.expect(locked, nil)
+	if keys, err := c.scan(c.s, c.key("ttl:*")); err != nil || fmt.Sprint(keys) != fmt.Sprint([]string{counted, persisted}) {
+		c.errorf("Scan(%q) after expiry = %q, %v, want [%q %q]", c.key("ttl:*"), keys, err, counted, persisted)
+	}
+	c.expect(persisted, &value)
+}
+
+func (c *checker) testConcurrency() {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := c.key(fmt.Sprintf("concurrency:%d", i%5))
+			for j := 0; j < 50; j++ {
+				err := c.s.Set(c.ctx, key, "value", time.Minute)
+				if err == nil {
+					_, err = c.s.GetString(c.ctx, key)
+					if err == redisrepo.ErrNotFound {
+						err = nil // deleted by another goroutine
+					}
+				}
+				if err == nil {
+					_, err = c.scan(c.s, c.key("concurrency:*"))
+				}
+				if err == nil {
+					_, err = c.s.Del(c.ctx, key)
+				}
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("concurrent use: %w", err))
+					mu.Unlock()
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		c.t.Error(err)
+	}
+}
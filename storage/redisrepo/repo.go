@@ -1,11 +1,157 @@
 package redisrepo
 
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golanguzb70/go-gin-bearer-auth-postgres-monolithic-template/config"
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrNotFound is returned when a key does not exist or has expired.
+var ErrNotFound = errors.New("redisrepo: key not found")
 
 type InMemoryStorageI interface {
-	Set(key, value string) error
-	SetWithTTL(key, value string, seconds int) error
-	Get(key string) (interface{}, error)
-	Exists(key string) (interface{}, error)
-	Del(key string) (interface{}, error)
-	Keys(pattern string) (interface{}, error)
+	// Ping checks the storage can be reached.
+	Ping(ctx context.Context) error
+	// Set sets key to value, expiring after ttl or never when ttl is 0.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX sets key to value only when it does not exist and reports
+	// whether it did.
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// SetJSON sets key to value marshaled as JSON.
+	SetJSON(ctx context.Context, key string, value any, ttl time.Duration) error
+	// GetString returns the value of key or ErrNotFound.
+	GetString(ctx context.Context, key string) (string, error)
+	// GetJSON unmarshals the value of key into dest or returns ErrNotFound.
+	GetJSON(ctx context.Context, key string, dest any) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// Incr increments the integer value of key and returns it. A key Incr
+	// creates expires after ttl, or never when ttl is 0.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Del deletes the keys and returns how many of them existed.
+	Del(ctx context.Context, keys ...string) (int, error)
+	// CompareAndDelete deletes key only when it holds value and reports
+	// whether it did.
+	CompareAndDelete(ctx context.Context, key, value string) (bool, error)
+	// Scan iterates over the keys matching the glob pattern without
+	// blocking the server like KEYS does.
+	Scan(ctx context.Context, pattern string) KeyIterator
+}
+
+// KeyIterator walks keys like SCAN: keys changed while iterating may or
+// may not be returned.
+type KeyIterator interface {
+	Next() bool
+	Key() string
+	Err() error
+}
+
+// New returns the storage chosen by cfg.InMemoryStorage, Redis through
+// pool or the memory of the process.
+func New(cfg config.Config, pool *redis.Pool) InMemoryStorageI {
+	if cfg.InMemoryStorage == "memory" {
+		return NewMemoryRepo()
+	}
+	return NewRedisRepo(pool)
+}
+
+// Namespaced returns s with every key prefixed by namespace and ":".
+func Namespaced(s InMemoryStorageI, namespace string) InMemoryStorageI {
+	return &namespaced{s: s, prefix: namespace + ":"}
+}
+
+type namespaced struct {
+	s      InMemoryStorageI
+	prefix string
+}
+
+func (n *namespaced) Ping(ctx context.Context) error {
+	return n.s.Ping(ctx)
+}
+
+func (n *namespaced) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return n.s.Set(ctx, n.prefix+key, value, ttl)
+}
+
+func (n *namespaced) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return n.s.SetNX(ctx, n.prefix+key, value, ttl)
+}
+
+func (n *namespaced) SetJSON(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return n.s.SetJSON(ctx, n.prefix+key, value, ttl)
+}
+
+func (n *namespaced) GetString(ctx context.Context, key string) (string, error) {
+	return n.s.GetString(ctx, n.prefix+key)
+}
+
+func (n *namespaced) GetJSON(ctx context.Context, key string, dest any) error {
+	return n.s.GetJSON(ctx, n.prefix+key, dest)
 }
+
+func (n *namespaced) Exists(ctx context.Context, key string) (bool, error) {
+	return n.s.Exists(ctx, n.prefix+key)
+}
+
+func (n *namespaced) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return n.s.Incr(ctx, n.prefix+key, ttl)
+}
+
+func (n *namespaced) Del(ctx context.Context, keys ...string) (int, error) {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = n.prefix + key
+	}
+	return n.s.Del(ctx, prefixed...)
+}
+
+func (n *namespaced) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	return n.s.CompareAndDelete(ctx, n.prefix+key, value)
+}
+
+func (n *namespaced) Scan(ctx context.Context, pattern string) KeyIterator {
+	return &namespacedIterator{KeyIterator: n.s.Scan(ctx, escapeGlob(n.prefix)+pattern), prefix: n.prefix}
+}
+
+type namespacedIterator struct {
+	KeyIterator
+	prefix string
+}
+
+func (it *namespacedIterator) Key() string {
+	return strings.TrimPrefix(it.KeyIterator.Key(), it.prefix)
+}
+
+// escapeGlob escapes the characters glob patterns treat specially.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[]\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// sliceIterator iterates over keys known up front.
+type sliceIterator struct {
+	keys []string
+	key  string
+	err  error
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.err != nil || len(it.keys) == 0 {
+		return false
+	}
+	it.key, it.keys = it.keys[0], it.keys[1:]
+	return true
+}
+
+func (it *sliceIterator) Key() string { return it.key }
+
+func (it *sliceIterator) Err() error { return it.err }